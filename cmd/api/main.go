@@ -5,30 +5,49 @@ import (
 	"github.com/rs/cors"
 	"log"
 	"mobigo-backend/internal/agreement"
+	"mobigo-backend/internal/audit"
 	"mobigo-backend/internal/booking"
+	"mobigo-backend/internal/branch"
+	"mobigo-backend/internal/campaign"
+	"mobigo-backend/internal/favourite"
 	"mobigo-backend/internal/installment"
+	"mobigo-backend/internal/kyc"
+	"mobigo-backend/internal/notification"
 	"mobigo-backend/internal/payment"
 	"mobigo-backend/internal/schedule"
 	"mobigo-backend/internal/task"
+	"mobigo-backend/internal/tradein"
 	"mobigo-backend/internal/user"
 	"mobigo-backend/internal/vehicle"
 	"mobigo-backend/internal/vehicleimage"
+	"mobigo-backend/internal/waitlist"
 	"net/http"
 	"time"
 
 	"mobigo-backend/pkg/database"
+	"mobigo-backend/pkg/mailer"
 )
 
 // apiHandlers is a container struct that holds all the different
 // feature handlers for our application.
 type apiHandlers struct {
 	userHandler         *user.Handler
+	adminUserHandler    *user.AdminHandler
+	profileHandler      *user.ProfileHandler
 	vehicleHandler      *vehicle.Handler
 	bookingHandler      *booking.Handler
 	scheduleHandler     *schedule.Handler
 	agreementHandler    *agreement.Handler
 	paymentHandler      *payment.Handler
 	vehicleImageHandler *vehicleimage.Handler // Add the vehicle image handler
+	notificationHandler *notification.Handler
+	waitlistHandler     *waitlist.Handler
+	auditHandler        *audit.Handler
+	kycHandler          *kyc.Handler
+	campaignHandler     *campaign.Handler
+	tradeInHandler      *tradein.Handler
+	favouriteHandler    *favourite.Handler
+	branchHandler       *branch.Handler
 }
 
 func main() {
@@ -37,6 +56,38 @@ func main() {
 	dbPassword := "" // Use your MySQL root password
 	dbName := "mobigo-db"
 	var jwtSecret = "a_very_secret_key_that_should_be_long_and_random"
+	// Access tokens are short-lived; clients renew them with their refresh token
+	// until the session itself expires.
+	tokenConfig := user.TokenConfig{
+		AccessTokenTTL:     15 * time.Minute,
+		StaffSessionTTL:    7 * 24 * time.Hour,
+		CustomerSessionTTL: 30 * 24 * time.Hour,
+		PasswordResetTTL:   1 * time.Hour,
+		PasswordResetURL:   "http://localhost:3000/reset-password",
+		VerificationTTL:    48 * time.Hour,
+		VerificationURL:    "http://localhost:3000/verify-email",
+	}
+	// Failed logins lock an account after 5 attempts and a client address after 20,
+	// starting at one minute and doubling with each further failure.
+	lockoutPolicy := user.LockoutPolicy{
+		AccountMaxAttempts: 5,
+		IPMaxAttempts:      20,
+		BaseLockout:        1 * time.Minute,
+		MaxLockout:         1 * time.Hour,
+		ResetAfter:         24 * time.Hour,
+	}
+	// Customer KYC documents are kept here, outside the publicly served ./uploads.
+	kycDocumentDir := "./private/kyc"
+	// Photos of customers' trade-in vehicles are kept here, outside the publicly served ./uploads.
+	tradeInPhotoDir := "./private/trade-ins"
+	// How long a customer has to accept a trade-in appraisal.
+	tradeInOfferTTL := 14 * 24 * time.Hour
+	// How long a staff invitation can be used to register.
+	staffInvitationTTL := 72 * time.Hour
+	// How long the next customer on a vehicle's waitlist has to book it.
+	waitlistHoldDuration := 24 * time.Hour
+	// How long after its proposed time an unconfirmed booking is expired.
+	pendingBookingGracePeriod := 1 * time.Hour
 
 	db, err := database.Connect(dbUser, dbPassword, dbName)
 	if err != nil {
@@ -44,6 +95,12 @@ func main() {
 	}
 	log.Println("Successfully connected to the database using GORM!")
 
+	// Outgoing email is written to ./mail as .eml files during local development.
+	mail, err := mailer.NewFileMailer("./mail")
+	if err != nil {
+		log.Fatalf("Could not set up the mailer: %v", err)
+	}
+
 	// --- Dependency Injection (Wiring) ---
 	// Build repositories
 	userRepository := user.NewGORMRepository(db)
@@ -54,38 +111,79 @@ func main() {
 	paymentRepository := payment.NewGORMRepository(db)
 	installmentRepository := installment.NewGORMRepository(db)
 	vehicleImageRepository := vehicleimage.NewGORMRepository(db) // New repository
+	notificationRepository := notification.NewGORMRepository(db)
+	auditRepository := audit.NewGORMRepository(db)
+	kycRepository := kyc.NewGORMRepository(db)
+	waitlistRepository := waitlist.NewGORMRepository(db)
+	campaignRepository := campaign.NewGORMRepository(db)
+	tradeInRepository := tradein.NewGORMRepository(db)
+	favouriteRepository := favourite.NewGORMRepository(db)
+	branchRepository := branch.NewGORMRepository(db)
 
 	// Build services
-	userService := user.NewService(userRepository, jwtSecret, 5*time.Second)
-	vehicleService := vehicle.NewService(vehicleRepository, 5*time.Second)
-	bookingService := booking.NewService(bookingRepository, scheduleRepository, vehicleRepository)
+	notificationService := notification.NewService(notificationRepository)
+	auditService := audit.NewService(auditRepository)
+	kycService := kyc.NewService(kycRepository, notificationService, kycDocumentDir)
+	waitlistService := waitlist.NewService(waitlistRepository, vehicleRepository, notificationService, waitlistHoldDuration)
+	userService := user.NewService(userRepository, jwtSecret, tokenConfig, lockoutPolicy, mail, auditService, 5*time.Second)
+	adminUserService := user.NewAdminService(userRepository, auditService, staffInvitationTTL)
+	profileService := user.NewProfileService(userRepository)
+	campaignService := campaign.NewService(campaignRepository)
+	tradeInService := tradein.NewService(tradeInRepository, notificationService, tradeInPhotoDir, tradeInOfferTTL)
+	branchService := branch.NewService(branchRepository, userRepository)
+	favouriteService := favourite.NewService(favouriteRepository, vehicleRepository, campaignService, notificationService)
+	// Whenever a vehicle becomes available again, the waitlist gets first refusal and
+	// customers who saved it are alerted.
+	vehicleReleased := vehicle.AvailabilityListeners{waitlistService, favouriteService}
+	vehicleService := vehicle.NewService(vehicleRepository, vehicleReleased, favouriteService, campaignService, branchService, 5*time.Second)
+	bookingService := booking.NewService(bookingRepository, scheduleRepository, vehicleRepository, waitlistService, vehicleReleased, branchService)
 	scheduleService := schedule.NewService(scheduleRepository)
-	paymentService := payment.NewService(paymentRepository, installmentRepository, vehicleRepository, agreementRepository, bookingRepository)
-	agreementService := agreement.NewService(agreementRepository, bookingRepository, paymentService)
+	paymentService := payment.NewService(paymentRepository, installmentRepository, vehicleRepository, agreementRepository, bookingRepository, kycService)
+	agreementService := agreement.NewService(agreementRepository, bookingRepository, paymentService, vehicleService, tradeInService)
 	vehicleImageService := vehicleimage.NewService(vehicleImageRepository) // New service
 
 	// Build handlers
 	userHandler := user.NewHandler(userService)
+	adminUserHandler := user.NewAdminHandler(adminUserService)
+	profileHandler := user.NewProfileHandler(profileService)
 	vehicleHandler := vehicle.NewHandler(vehicleService)
 	bookingHandler := booking.NewHandler(bookingService)
 	scheduleHandler := schedule.NewHandler(scheduleService)
 	agreementHandler := agreement.NewHandler(agreementService)
 	paymentHandler := payment.NewHandler(paymentService)
 	vehicleImageHandler := vehicleimage.NewHandler(vehicleImageService) // New handler
+	notificationHandler := notification.NewHandler(notificationService)
+	waitlistHandler := waitlist.NewHandler(waitlistService)
+	auditHandler := audit.NewHandler(auditService)
+	kycHandler := kyc.NewHandler(kycService)
+	campaignHandler := campaign.NewHandler(campaignService)
+	tradeInHandler := tradein.NewHandler(tradeInService)
+	favouriteHandler := favourite.NewHandler(favouriteService)
+	branchHandler := branch.NewHandler(branchService)
 
 	// 3. Create the master handler container
 	handlers := &apiHandlers{
 		userHandler:         userHandler,
+		adminUserHandler:    adminUserHandler,
+		profileHandler:      profileHandler,
 		vehicleHandler:      vehicleHandler,
 		bookingHandler:      bookingHandler,
 		scheduleHandler:     scheduleHandler,
 		agreementHandler:    agreementHandler,
 		paymentHandler:      paymentHandler,
 		vehicleImageHandler: vehicleImageHandler, // Add handler to the container
+		notificationHandler: notificationHandler,
+		waitlistHandler:     waitlistHandler,
+		auditHandler:        auditHandler,
+		kycHandler:          kycHandler,
+		campaignHandler:     campaignHandler,
+		tradeInHandler:      tradeInHandler,
+		favouriteHandler:    favouriteHandler,
+		branchHandler:       branchHandler,
 	}
 
 	// 4. Define Routes
-	router := defineRoutes(handlers, jwtSecret)
+	router := defineRoutes(handlers, jwtSecret, userService, userService, userService)
 
 	// --- Setup Cron Jobs ---
 	c := cron.New()
@@ -95,6 +193,21 @@ func main() {
 	if err != nil {
 		log.Fatalf("Could not add cron job: %v", err)
 	}
+	waitlistHoldChecker := task.NewWaitlistHoldChecker(waitlistService)
+	_, err = c.AddFunc("*/15 * * * *", waitlistHoldChecker.Run)
+	if err != nil {
+		log.Fatalf("Could not add cron job: %v", err)
+	}
+	bookingExpiryChecker := task.NewBookingExpiryChecker(bookingRepository, waitlistService, notificationService, pendingBookingGracePeriod)
+	_, err = c.AddFunc("*/10 * * * *", bookingExpiryChecker.Run)
+	if err != nil {
+		log.Fatalf("Could not add cron job: %v", err)
+	}
+	campaignAnnouncer := task.NewCampaignAnnouncer(favouriteService)
+	_, err = c.AddFunc("*/15 * * * *", campaignAnnouncer.Run)
+	if err != nil {
+		log.Fatalf("Could not add cron job: %v", err)
+	}
 	c.Start()
 	log.Println("Cron job scheduler started. Penalty check will run daily at midnight.")
 	defer c.Stop()
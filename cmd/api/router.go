@@ -8,10 +8,13 @@ import (
 )
 
 // defineRoutes now accepts the apiHandlers container, giving it access to all handlers.
-func defineRoutes(handlers *apiHandlers, jwtSecret string) *mux.Router {
+func defineRoutes(handlers *apiHandlers, jwtSecret string, roleResolver middleware.RoleResolver, sessions middleware.SessionValidator, verifier middleware.EmailVerifier) *mux.Router {
 	router := mux.NewRouter()
 
-	authMiddleware := middleware.JWTAuthMiddleware(jwtSecret)
+	// authMiddleware also rejects access tokens whose session has been logged out.
+	authMiddleware := middleware.SessionAuthMiddleware(jwtSecret, sessions)
+	// authz enforces per-route role requirements on top of authMiddleware.
+	authz := middleware.NewAuthorizer(roleResolver, verifier)
 
 	// --- Serve Static Files ---
 	// This is crucial. It creates a route that allows the frontend to access
@@ -20,13 +23,23 @@ func defineRoutes(handlers *apiHandlers, jwtSecret string) *mux.Router {
 	router.PathPrefix("/uploads/").Handler(http.StripPrefix("/uploads/", fs))
 
 	// Pass the middleware to the handlers that need it
-	handlers.userHandler.RegisterRoutes(router)
-	handlers.vehicleHandler.RegisterRoutes(router, authMiddleware)
-	handlers.bookingHandler.RegisterRoutes(router, authMiddleware)
-	handlers.scheduleHandler.RegisterRoutes(router, authMiddleware)
-	handlers.agreementHandler.RegisterRoutes(router, authMiddleware)
-	handlers.paymentHandler.RegisterRoutes(router, authMiddleware)
-	handlers.vehicleImageHandler.RegisterRoutes(router, authMiddleware) // This registers all image-related routes
+	handlers.userHandler.RegisterRoutes(router, authMiddleware, authz)
+	handlers.adminUserHandler.RegisterRoutes(router, authMiddleware, authz)
+	handlers.profileHandler.RegisterRoutes(router, authMiddleware, authz)
+	handlers.vehicleHandler.RegisterRoutes(router, authMiddleware, authz)
+	handlers.bookingHandler.RegisterRoutes(router, authMiddleware, authz)
+	handlers.scheduleHandler.RegisterRoutes(router, authMiddleware, authz)
+	handlers.agreementHandler.RegisterRoutes(router, authMiddleware, authz)
+	handlers.paymentHandler.RegisterRoutes(router, authMiddleware, authz)
+	handlers.vehicleImageHandler.RegisterRoutes(router, authMiddleware, authz) // This registers all image-related routes
+	handlers.notificationHandler.RegisterRoutes(router, authMiddleware)
+	handlers.waitlistHandler.RegisterRoutes(router, authMiddleware, authz)
+	handlers.auditHandler.RegisterRoutes(router, authMiddleware, authz)
+	handlers.kycHandler.RegisterRoutes(router, authMiddleware, authz)
+	handlers.campaignHandler.RegisterRoutes(router, authMiddleware, authz)
+	handlers.tradeInHandler.RegisterRoutes(router, authMiddleware, authz)
+	handlers.favouriteHandler.RegisterRoutes(router, authMiddleware, authz)
+	handlers.branchHandler.RegisterRoutes(router, authMiddleware, authz)
 
 	// General-purpose routes
 	router.HandleFunc("/api/health", func(w http.ResponseWriter, r *http.Request) {
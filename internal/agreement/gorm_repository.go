@@ -11,7 +11,24 @@ type gormRepository struct{ db *gorm.DB }
 func NewGORMRepository(db *gorm.DB) Repository { return &gormRepository{db: db} }
 
 func (r *gormRepository) CreateAgreement(ctx context.Context, agreement *domain.Agreement) error {
-	return r.db.WithContext(ctx).Create(agreement).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(agreement).Error; err != nil {
+			return err
+		}
+		if agreement.TradeInID == nil {
+			return nil
+		}
+		result := tx.Model(&domain.TradeIn{}).
+			Where("id = ? AND status = ?", *agreement.TradeInID, domain.TradeInStatusAccepted).
+			Updates(map[string]interface{}{"status": domain.TradeInStatusApplied, "agreement_id": agreement.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTradeInUnavailable
+		}
+		return nil
+	})
 }
 
 func (r *gormRepository) GetByID(ctx context.Context, id int64) (*domain.Agreement, error) {
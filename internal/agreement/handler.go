@@ -5,6 +5,7 @@ package agreement
 import (
 	"encoding/json"
 	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/middleware"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -21,17 +22,19 @@ func NewHandler(s Service) *Handler {
 	}
 }
 
-func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler) {
+func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler, authz *middleware.Authorizer) {
 	r := router.PathPrefix("/api/agreements").Subrouter()
 	r.Use(authMiddleware)
+	r.Use(authz.Require(domain.RoleStaff, domain.RoleAdmin))
 	r.HandleFunc("", h.createAgreementHandler).Methods("POST")
 }
 
 type createAgreementRequest struct {
 	BookingID   int64              `json:"booking_id"`
-	FinalPrice  float64            `json:"final_price"`
+	FinalPrice  float64            `json:"final_price"` // Omit to use the vehicle's effective price
 	PaymentType domain.PaymentType `json:"payment_type"`
 	Terms       string             `json:"terms"`
+	TradeInID   *int64             `json:"trade_in_id"` // An accepted trade-in to credit, if any
 }
 
 // THE FIX: The handler's only job is to translate the request and call its own service.
@@ -42,7 +45,7 @@ func (h *Handler) createAgreementHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	agreement, err := h.service.CreateAgreement(r.Context(), req.BookingID, req.FinalPrice, req.PaymentType, req.Terms)
+	agreement, err := h.service.CreateAgreement(r.Context(), req.BookingID, req.FinalPrice, req.PaymentType, req.Terms, req.TradeInID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
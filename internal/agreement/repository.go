@@ -2,10 +2,17 @@ package agreement
 
 import (
 	"context"
+	"errors"
 	"mobigo-backend/internal/domain"
 )
 
+// ErrTradeInUnavailable is returned when the agreement's trade-in stopped being an
+// accepted offer, e.g. it was withdrawn or credited on another agreement meanwhile.
+var ErrTradeInUnavailable = errors.New("trade-in offer is no longer available")
+
 type Repository interface {
+	// CreateAgreement saves the agreement. If it credits a trade-in, the trade-in is
+	// moved from accepted to applied in the same transaction.
 	CreateAgreement(ctx context.Context, agreement *domain.Agreement) error
 	// New method to fetch an agreement by its ID
 	GetByID(ctx context.Context, id int64) (*domain.Agreement, error)
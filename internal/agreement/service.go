@@ -14,8 +14,22 @@ type PaymentCreator interface {
 	CreateFullPaymentForAgreement(ctx context.Context, agreementID int64) error
 }
 
+// VehiclePricer provides vehicles with their effective (discounted) price.
+type VehiclePricer interface {
+	GetVehicleByID(ctx context.Context, id int64) (*domain.Vehicle, error)
+}
+
+// TradeInCredits looks up accepted trade-in offers. The repository claims the offer
+// when it creates the agreement.
+type TradeInCredits interface {
+	AcceptedCredit(ctx context.Context, tradeInID, userID int64) (float64, error)
+}
+
 type Service interface {
-	CreateAgreement(ctx context.Context, bookingID int64, finalPrice float64, paymentType domain.PaymentType, terms string) (*domain.Agreement, error)
+	// CreateAgreement creates an agreement for a confirmed booking. A finalPrice of zero
+	// defaults to the vehicle's effective price, so running discounts are honoured. If
+	// tradeInID is set, that accepted trade-in of the booking's customer is credited.
+	CreateAgreement(ctx context.Context, bookingID int64, finalPrice float64, paymentType domain.PaymentType, terms string, tradeInID *int64) (*domain.Agreement, error)
 	GetByID(ctx context.Context, id int64) (*domain.Agreement, error)
 }
 
@@ -23,19 +37,23 @@ type service struct {
 	repo           Repository
 	bookingRepo    booking.Repository
 	paymentCreator PaymentCreator // THE FIX: The service now depends on the interface, not a concrete type.
+	vehicles       VehiclePricer
+	tradeIns       TradeInCredits
 }
 
 // THE FIX: The constructor now accepts any struct that fulfills the PaymentCreator contract.
-func NewService(repo Repository, bookingRepo booking.Repository, pc PaymentCreator) Service {
+func NewService(repo Repository, bookingRepo booking.Repository, pc PaymentCreator, vehicles VehiclePricer, tradeIns TradeInCredits) Service {
 	return &service{
 		repo:           repo,
 		bookingRepo:    bookingRepo,
 		paymentCreator: pc,
+		vehicles:       vehicles,
+		tradeIns:       tradeIns,
 	}
 }
 
 // THE FIX: The service now contains the full business logic, orchestrated correctly.
-func (s *service) CreateAgreement(ctx context.Context, bookingID int64, finalPrice float64, paymentType domain.PaymentType, terms string) (*domain.Agreement, error) {
+func (s *service) CreateAgreement(ctx context.Context, bookingID int64, finalPrice float64, paymentType domain.PaymentType, terms string, tradeInID *int64) (*domain.Agreement, error) {
 	// --- Validation ---
 	booking, err := s.bookingRepo.GetBookingByID(ctx, bookingID)
 	if err != nil || booking == nil {
@@ -44,6 +62,32 @@ func (s *service) CreateAgreement(ctx context.Context, bookingID int64, finalPri
 	if booking.Status != domain.BookingStatusConfirmed {
 		return nil, errors.New("agreement can only be created for confirmed bookings")
 	}
+	if finalPrice < 0 {
+		return nil, errors.New("final price cannot be negative")
+	}
+	if finalPrice == 0 {
+		vehicle, err := s.vehicles.GetVehicleByID(ctx, booking.VehicleID)
+		if err != nil || vehicle == nil {
+			return nil, errors.New("could not determine the vehicle price")
+		}
+		finalPrice = vehicle.EffectivePrice
+		if finalPrice <= 0 {
+			return nil, errors.New("vehicle has no price; give a final price")
+		}
+	}
+
+	// The trade-in credit is a line on the agreement; payments are for what remains.
+	var tradeInCredit float64
+	if tradeInID != nil {
+		credit, err := s.tradeIns.AcceptedCredit(ctx, *tradeInID, booking.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if credit >= finalPrice {
+			return nil, errors.New("trade-in credit must be less than the final price")
+		}
+		tradeInCredit = credit
+	}
 
 	// --- Create the Agreement Record ---
 	newAgreement := &domain.Agreement{
@@ -52,6 +96,8 @@ func (s *service) CreateAgreement(ctx context.Context, bookingID int64, finalPri
 		PaymentType:   paymentType,
 		Terms:         terms,
 		AgreementDate: time.Now(),
+		TradeInID:     tradeInID,
+		TradeInCredit: tradeInCredit,
 	}
 	if err := s.repo.CreateAgreement(ctx, newAgreement); err != nil {
 		return nil, err
@@ -0,0 +1,46 @@
+package audit
+
+import (
+	"context"
+	"mobigo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type gormRepository struct {
+	db *gorm.DB
+}
+
+func NewGORMRepository(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) Create(ctx context.Context, entry *domain.AuditLog) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *gormRepository) List(ctx context.Context, filter Filter) ([]*domain.AuditLog, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.AuditLog{})
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.TargetID != 0 {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []*domain.AuditLog
+	err := query.Order("created_at desc, id desc").
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&entries).Error
+	return entries, total, err
+}
@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"encoding/json"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(s Service) *Handler {
+	return &Handler{service: s}
+}
+
+// RegisterRoutes sets up the admin-only audit log routes.
+func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler, authz *middleware.Authorizer) {
+	r := router.PathPrefix("/api/admin/audit-logs").Subrouter()
+	r.Use(authMiddleware, authz.Require(domain.RoleAdmin))
+
+	r.HandleFunc("", h.listAuditLogsHandler).Methods("GET")
+}
+
+type listAuditLogsResponse struct {
+	Data     []*domain.AuditLog `json:"data"`
+	Total    int64              `json:"total"`
+	Page     int                `json:"page"`
+	PageSize int                `json:"page_size"`
+}
+
+// listAuditLogsHandler lists audit entries. Query params: action, actor_id, target_id, page, page_size.
+func (h *Handler) listAuditLogsHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := Filter{Action: domain.AuditAction(q.Get("action"))}
+	filter.ActorID, _ = strconv.ParseInt(q.Get("actor_id"), 10, 64)
+	filter.TargetID, _ = strconv.ParseInt(q.Get("target_id"), 10, 64)
+	filter.Page, _ = strconv.Atoi(q.Get("page"))
+	filter.PageSize, _ = strconv.Atoi(q.Get("page_size"))
+	filter = normalizePaging(filter)
+
+	entries, total, err := h.service.List(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "Failed to retrieve audit logs", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(listAuditLogsResponse{Data: entries, Total: total, Page: filter.Page, PageSize: filter.PageSize})
+}
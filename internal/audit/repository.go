@@ -0,0 +1,22 @@
+package audit
+
+import (
+	"context"
+	"mobigo-backend/internal/domain"
+)
+
+// Filter narrows down the audit log. Zero values are ignored.
+type Filter struct {
+	Action   domain.AuditAction
+	ActorID  int64
+	TargetID int64
+	Page     int
+	PageSize int
+}
+
+// Repository defines the interface for audit log storage. Entries are append-only.
+type Repository interface {
+	Create(ctx context.Context, entry *domain.AuditLog) error
+	// List returns one page of entries matching the filter, newest first, plus the total match count.
+	List(ctx context.Context, filter Filter) ([]*domain.AuditLog, int64, error)
+}
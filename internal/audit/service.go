@@ -0,0 +1,46 @@
+package audit
+
+import (
+	"context"
+	"mobigo-backend/internal/domain"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// Service records and lists security-relevant events.
+type Service interface {
+	Record(ctx context.Context, entry *domain.AuditLog) error
+	List(ctx context.Context, filter Filter) ([]*domain.AuditLog, int64, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) Record(ctx context.Context, entry *domain.AuditLog) error {
+	return s.repo.Create(ctx, entry)
+}
+
+func (s *service) List(ctx context.Context, filter Filter) ([]*domain.AuditLog, int64, error) {
+	return s.repo.List(ctx, normalizePaging(filter))
+}
+
+func normalizePaging(filter Filter) Filter {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultPageSize
+	}
+	if filter.PageSize > maxPageSize {
+		filter.PageSize = maxPageSize
+	}
+	return filter
+}
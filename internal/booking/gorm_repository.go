@@ -3,7 +3,10 @@ package booking
 import (
 	"context"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"mobigo-backend/internal/domain"
+	"mobigo-backend/internal/schedule"
+	"time"
 )
 
 type gormRepository struct {
@@ -19,16 +22,54 @@ func (r *gormRepository) CreateBooking(ctx context.Context, booking *domain.Book
 	return r.db.WithContext(ctx).Create(booking).Error
 }
 
-// GetAllBookings retrieves all booking records.
+// FindBookings retrieves the booking records that match the filter.
 // It uses GORM's Preload feature to automatically fetch the related
 // User and Vehicle data for each booking, which is very powerful.
-func (r *gormRepository) GetAllBookings(ctx context.Context) ([]*domain.Booking, error) {
+func (r *gormRepository) FindBookings(ctx context.Context, filter Filter) ([]*domain.Booking, error) {
+	query := r.db.WithContext(ctx).
+		Preload("User").               // Load the associated User
+		Preload("Vehicle").            // Load the associated Vehicle
+		Preload("Agreement.Payments"). // Preload Payments related to the Agreement
+		Order("created_at desc")       // Show newest bookings first
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.VehicleID != 0 {
+		query = query.Where("vehicle_id = ?", filter.VehicleID)
+	}
+	if filter.CustomerID != 0 {
+		query = query.Where("user_id = ?", filter.CustomerID)
+	}
+	if filter.BranchID != 0 {
+		atBranch := r.db.Model(&domain.Vehicle{}).Select("id")
+		if filter.IncludeUnassigned {
+			atBranch = atBranch.Where("branch_id = ? OR branch_id IS NULL", filter.BranchID)
+		} else {
+			atBranch = atBranch.Where("branch_id = ?", filter.BranchID)
+		}
+		query = query.Where("vehicle_id IN (?)", atBranch)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	var bookings []*domain.Booking
+	err := query.Find(&bookings).Error
+	return bookings, err
+}
+
+// GetBookingsByUser leaves out the User preload: the caller is that user.
+func (r *gormRepository) GetBookingsByUser(ctx context.Context, userID int64) ([]*domain.Booking, error) {
 	var bookings []*domain.Booking
 	err := r.db.WithContext(ctx).
-		Preload("User"). // Load the associated User
-		Preload("Vehicle"). // Load the associated Vehicle
-		Preload("Agreement.Payments"). // Preload Payments related to the Agreement
-		Order("created_at desc"). // Show newest bookings first
+		Preload("Vehicle.Images").
+		Preload("Agreement.Payments").
+		Where("user_id = ?", userID).
+		Order("created_at desc").
 		Find(&bookings).Error
 	return bookings, err
 }
@@ -53,3 +94,188 @@ func (r *gormRepository) GetBookingByID(ctx context.Context, id int64) (*domain.
 func (r *gormRepository) UpdateBooking(ctx context.Context, booking *domain.Booking) error {
 	return r.db.WithContext(ctx).Save(booking).Error
 }
+
+// openBookingStatuses are the statuses in which a booking still competes for its vehicle.
+var openBookingStatuses = []domain.BookingStatus{
+	domain.BookingStatusPending,
+	domain.BookingStatusConfirmed,
+	domain.BookingStatusRescheduleRequested,
+}
+
+func (r *gormRepository) HasOpenBooking(ctx context.Context, userID, vehicleID int64) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.Booking{}).
+		Where("user_id = ? AND vehicle_id = ? AND status IN ?", userID, vehicleID, openBookingStatuses).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *gormRepository) ConfirmBooking(ctx context.Context, bookingID int64, schedule *domain.Schedule, competitorReason string) (*domain.Booking, []*domain.Booking, error) {
+	var confirmed domain.Booking
+	var declined []*domain.Booking
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		forUpdate := clause.Locking{Strength: "UPDATE"}
+
+		if err := tx.Clauses(forUpdate).First(&confirmed, bookingID).Error; err != nil {
+			return err
+		}
+		if confirmed.Status != domain.BookingStatusPending {
+			return ErrBookingNotPending
+		}
+
+		// Locking the vehicle row is what serializes competing confirmations:
+		// the second transaction blocks here and then sees the vehicle as booked.
+		var vehicle domain.Vehicle
+		if err := tx.Clauses(forUpdate).First(&vehicle, confirmed.VehicleID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				// Not to be mistaken for the booking itself being missing.
+				return ErrVehicleNotFound
+			}
+			return err
+		}
+		if vehicle.Status != domain.VehicleStatusAvailable {
+			return ErrVehicleUnavailable
+		}
+		if vehicle.BranchID != nil {
+			if err := checkBranchSlot(tx, *vehicle.BranchID, schedule); err != nil {
+				return err
+			}
+			schedule.BranchID = vehicle.BranchID
+		}
+
+		if err := tx.Model(&vehicle).Update("status", domain.VehicleStatusBooked).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&confirmed).Update("status", domain.BookingStatusConfirmed).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(schedule).Error; err != nil {
+			return err
+		}
+
+		// Every other open booking for this vehicle has now lost the race.
+		if err := tx.Clauses(forUpdate).
+			Where("vehicle_id = ? AND id <> ? AND status IN ?", confirmed.VehicleID, confirmed.ID,
+				[]domain.BookingStatus{domain.BookingStatusPending, domain.BookingStatusRescheduleRequested}).
+			Find(&declined).Error; err != nil {
+			return err
+		}
+		for _, b := range declined {
+			b.Status = domain.BookingStatusDeclined
+			b.DeclineReason = &competitorReason
+			if err := tx.Model(b).Updates(map[string]interface{}{
+				"status":         b.Status,
+				"decline_reason": competitorReason,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	return &confirmed, declined, nil
+}
+
+// checkBranchSlot checks that the staff member hosting the viewing works at the branch,
+// or has no branch, and that the branch is not already hosting as many viewings as it
+// can at that time. The branch row is locked so confirmations at one branch serialize.
+func checkBranchSlot(tx *gorm.DB, branchID int64, sch *domain.Schedule) error {
+	var staff domain.User
+	if err := tx.Select("id", "branch_id").First(&staff, sch.UserID).Error; err != nil {
+		return err
+	}
+	if staff.BranchID != nil && *staff.BranchID != branchID {
+		return ErrWrongBranch
+	}
+
+	var branch domain.Branch
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&branch, branchID).Error; err != nil {
+		return err
+	}
+	var overlapping int64
+	err := tx.Model(&domain.Schedule{}).
+		Where("branch_id = ? AND status = ? AND appointment_datetime > ? AND appointment_datetime < ?",
+			branchID, domain.ScheduleStatusScheduled,
+			sch.AppointmentDatetime.Add(-schedule.AppointmentDuration), sch.AppointmentDatetime.Add(schedule.AppointmentDuration)).
+		Count(&overlapping).Error
+	if err != nil {
+		return err
+	}
+	if overlapping >= int64(branch.ViewingCapacity) {
+		return ErrNoViewingSlot
+	}
+	return nil
+}
+
+func (r *gormRepository) CancelBooking(ctx context.Context, bookingID int64) (*domain.Booking, bool, error) {
+	var booking domain.Booking
+	released := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		forUpdate := clause.Locking{Strength: "UPDATE"}
+
+		if err := tx.Clauses(forUpdate).First(&booking, bookingID).Error; err != nil {
+			return err
+		}
+		switch booking.Status {
+		case domain.BookingStatusCancelled, domain.BookingStatusCompleted, domain.BookingStatusDeclined, domain.BookingStatusExpired:
+			return ErrBookingNotCancelled
+		}
+
+		// Only a confirmed booking holds the vehicle, so only it gives the vehicle back.
+		if booking.Status == domain.BookingStatusConfirmed {
+			result := tx.Model(&domain.Vehicle{}).
+				Where("id = ? AND status = ?", booking.VehicleID, domain.VehicleStatusBooked).
+				Update("status", domain.VehicleStatusAvailable)
+			if result.Error != nil {
+				return result.Error
+			}
+			released = result.RowsAffected == 1
+			if err := tx.Model(&domain.Schedule{}).
+				Where("booking_id = ? AND status = ?", booking.ID, domain.ScheduleStatusScheduled).
+				Update("status", domain.ScheduleStatusCancelled).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&booking).Update("status", domain.BookingStatusCancelled).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return &booking, released, nil
+}
+
+func (r *gormRepository) FindStalePendingBookings(ctx context.Context, cutoff time.Time) ([]*domain.Booking, error) {
+	var bookings []*domain.Booking
+	err := r.db.WithContext(ctx).
+		Preload("Vehicle").
+		Where("status = ? AND proposed_datetime < ?", domain.BookingStatusPending, cutoff).
+		Find(&bookings).Error
+	return bookings, err
+}
+
+func (r *gormRepository) DeclineBooking(ctx context.Context, bookingID int64, reason string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.Booking{}).
+		Where("id = ? AND status = ?", bookingID, domain.BookingStatusPending).
+		Updates(map[string]interface{}{
+			"status":            domain.BookingStatusRescheduleRequested,
+			"proposed_datetime": nil,
+			"decline_reason":    reason,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *gormRepository) ExpireBooking(ctx context.Context, bookingID int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.Booking{}).
+		Where("id = ? AND status = ?", bookingID, domain.BookingStatusPending).
+		Update("status", domain.BookingStatusExpired)
+	return result.RowsAffected > 0, result.Error
+}
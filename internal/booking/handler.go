@@ -4,8 +4,10 @@ package booking
 
 import (
 	"encoding/json"
+	"errors"
 	"mobigo-backend/internal/domain"
 	"mobigo-backend/pkg/middleware"
+	"mobigo-backend/pkg/timeparam"
 	"net/http"
 	"strconv"
 	"time"
@@ -21,16 +23,27 @@ func NewHandler(s Service) *Handler {
 	return &Handler{service: s}
 }
 
-func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler) {
+func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler, authz *middleware.Authorizer) {
 	r := router.PathPrefix("/api/bookings").Subrouter()
 	r.Use(authMiddleware)
 
-	r.HandleFunc("", h.getAllBookingsHandler).Methods("GET")
-	r.HandleFunc("", h.createBookingHandler).Methods("POST")
-	r.HandleFunc("/{id}", h.getBookingByIDHandler).Methods("GET")
-	r.HandleFunc("/{id}/confirm", h.confirmScheduleHandler).Methods("POST")
-	r.HandleFunc("/{id}/decline", h.declineBookingHandler).Methods("PUT")
-	r.HandleFunc("/{id}/status", h.updateBookingStatusHandler).Methods("PUT")
+	customerOnly := authz.Require(domain.RoleCustomer)
+	verified := authz.RequireVerified()
+	staffOnly := authz.Require(domain.RoleStaff, domain.RoleAdmin)
+
+	// Customer routes: always scoped to the user in the JWT.
+	// Only customers with a verified email address may book.
+	r.Handle("", customerOnly(verified(http.HandlerFunc(h.createBookingHandler)))).Methods("POST")
+	r.Handle("/me", customerOnly(http.HandlerFunc(h.getMyBookingsHandler))).Methods("GET")
+	r.Handle("/me/{id:[0-9]+}", customerOnly(http.HandlerFunc(h.getMyBookingHandler))).Methods("GET")
+	r.Handle("/me/{id:[0-9]+}/cancel", customerOnly(http.HandlerFunc(h.cancelMyBookingHandler))).Methods("PUT")
+
+	// Staff routes: see every customer's bookings.
+	r.Handle("", staffOnly(http.HandlerFunc(h.getAllBookingsHandler))).Methods("GET")
+	r.Handle("/{id:[0-9]+}", staffOnly(http.HandlerFunc(h.getBookingByIDHandler))).Methods("GET")
+	r.Handle("/{id:[0-9]+}/confirm", staffOnly(http.HandlerFunc(h.confirmScheduleHandler))).Methods("POST")
+	r.Handle("/{id:[0-9]+}/decline", staffOnly(http.HandlerFunc(h.declineBookingHandler))).Methods("PUT")
+	r.Handle("/{id:[0-9]+}/status", staffOnly(http.HandlerFunc(h.updateBookingStatusHandler))).Methods("PUT")
 }
 
 // --- Request/Response Structs ---
@@ -70,7 +83,17 @@ func (h *Handler) createBookingHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	booking, err := h.service.CreateBooking(r.Context(), userID, req.VehicleID, proposedTime)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		switch err.Error() {
+		case "vehicle not found":
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case "you already have an open booking for this vehicle",
+			"vehicle is on hold for another customer",
+			"vehicle is being offered to customers on its waitlist",
+			"vehicle is not available for booking, join its waitlist to be notified":
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -91,6 +114,14 @@ func (h *Handler) confirmScheduleHandler(w http.ResponseWriter, r *http.Request)
 
 	schedule, err := h.service.ConfirmSchedule(r.Context(), bookingID, staffID, req.Notes)
 	if err != nil {
+		if err == ErrVehicleUnavailable || err == ErrBookingNotPending || err == ErrWrongBranch || err == ErrNoViewingSlot {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err == ErrVehicleNotFound || err.Error() == "booking not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -111,7 +142,14 @@ func (h *Handler) declineBookingHandler(w http.ResponseWriter, r *http.Request)
 
 	updatedBooking, err := h.service.DeclineBooking(r.Context(), bookingID, req.Reason)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		switch err.Error() {
+		case "booking not found":
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case "only pending bookings can be declined":
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -139,6 +177,10 @@ func (h *Handler) updateBookingStatusHandler(w http.ResponseWriter, r *http.Requ
 	}
 	updatedBooking, err := h.service.UpdateBookingStatus(r.Context(), id, newStatus)
 	if err != nil {
+		if err == ErrBookingNotCancelled {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -147,8 +189,17 @@ func (h *Handler) updateBookingStatusHandler(w http.ResponseWriter, r *http.Requ
 	json.NewEncoder(w).Encode(updatedBooking)
 }
 
+// getAllBookingsHandler lists bookings for staff. Optional query params:
+// status, vehicle_id, customer_id, from/to (YYYY-MM-DD or RFC3339) on the booking's creation time,
+// and branch_id (a branch ID, or "all"; staff default to their own branch).
 func (h *Handler) getAllBookingsHandler(w http.ResponseWriter, r *http.Request) {
-	bookings, err := h.service.ListAllBookings(r.Context())
+	filter, err := parseFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.ViewerID, _ = r.Context().Value(middleware.UserIDKey).(int64)
+	bookings, err := h.service.ListBookings(r.Context(), filter)
 	if err != nil {
 		http.Error(w, "Failed to retrieve bookings", http.StatusInternalServerError)
 		return
@@ -178,3 +229,122 @@ func (h *Handler) getBookingByIDHandler(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(booking)
 }
+
+// --- Customer Handlers ---
+
+func (h *Handler) getMyBookingsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	bookings, err := h.service.ListCustomerBookings(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve bookings", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(bookings)
+}
+
+func (h *Handler) getMyBookingHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid booking ID", http.StatusBadRequest)
+		return
+	}
+	booking, err := h.service.GetCustomerBooking(r.Context(), userID, id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve booking", http.StatusInternalServerError)
+		return
+	}
+	if booking == nil {
+		http.Error(w, "Booking not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(booking)
+}
+
+func (h *Handler) cancelMyBookingHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid booking ID", http.StatusBadRequest)
+		return
+	}
+	booking, err := h.service.CancelCustomerBooking(r.Context(), userID, id)
+	if err != nil {
+		if err.Error() == "booking not found" {
+			http.Error(w, "Booking not found", http.StatusNotFound)
+			return
+		}
+		if err == ErrBookingNotCancelled {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to cancel booking", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(booking)
+}
+
+// parseFilter turns the staff list query string into a Filter.
+func parseFilter(r *http.Request) (Filter, error) {
+	q := r.URL.Query()
+	filter := Filter{Status: domain.BookingStatus(q.Get("status"))}
+
+	if raw := q.Get("vehicle_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return filter, errors.New("invalid vehicle_id")
+		}
+		filter.VehicleID = id
+	}
+	switch raw := q.Get("branch_id"); raw {
+	case "":
+	case "all":
+		filter.AllBranches = true
+	default:
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return filter, errors.New("invalid branch_id")
+		}
+		filter.BranchID = id
+	}
+	if raw := q.Get("customer_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return filter, errors.New("invalid customer_id")
+		}
+		filter.CustomerID = id
+	}
+	if raw := q.Get("from"); raw != "" {
+		from, err := timeparam.Parse(raw)
+		if err != nil {
+			return filter, errors.New("invalid from, use YYYY-MM-DD or RFC3339")
+		}
+		filter.From = &from
+	}
+	if raw := q.Get("to"); raw != "" {
+		to, err := timeparam.Parse(raw)
+		if err != nil {
+			return filter, errors.New("invalid to, use YYYY-MM-DD or RFC3339")
+		}
+		filter.To = &to
+	}
+	return filter, nil
+}
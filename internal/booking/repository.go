@@ -2,13 +2,68 @@ package booking
 
 import (
 	"context"
+	"errors"
 	"mobigo-backend/internal/domain"
+	"time"
 )
 
+// Errors returned by the transactional repository methods when the data
+// changed between the service's read and the locked write.
+var (
+	ErrBookingNotPending   = errors.New("only pending bookings can be confirmed")
+	ErrVehicleUnavailable  = errors.New("vehicle is no longer available")
+	ErrVehicleNotFound     = errors.New("vehicle not found")
+	ErrBookingNotCancelled = errors.New("booking can no longer be cancelled")
+	ErrWrongBranch         = errors.New("the vehicle is kept at another branch")
+	ErrNoViewingSlot       = errors.New("the branch has no free viewing slot at that time")
+)
+
+// Filter narrows down the staff booking list. Zero values are ignored.
+type Filter struct {
+	Status     domain.BookingStatus
+	VehicleID  int64
+	CustomerID int64
+	From       *time.Time // Inclusive lower bound on created_at
+	To         *time.Time // Exclusive upper bound on created_at
+	BranchID   int64      // Only bookings for vehicles kept at this branch
+	// IncludeUnassigned widens BranchID to vehicles not assigned to any branch yet.
+	IncludeUnassigned bool
+
+	// ViewerID is the staff member listing bookings. When BranchID is 0 and
+	// AllBranches is false, the list defaults to the viewer's home branch and
+	// vehicles not assigned to a branch yet.
+	ViewerID    int64
+	AllBranches bool
+}
+
 // Repository is the interface that provides booking storage methods.
 type Repository interface {
-	GetAllBookings(ctx context.Context) ([]*domain.Booking, error)
+	// FindBookings returns the bookings matching the filter, newest first.
+	FindBookings(ctx context.Context, filter Filter) ([]*domain.Booking, error)
+	// GetBookingsByUser returns a customer's own bookings, newest first.
+	GetBookingsByUser(ctx context.Context, userID int64) ([]*domain.Booking, error)
 	GetBookingByID(ctx context.Context, id int64) (*domain.Booking, error) // New method
 	UpdateBooking(ctx context.Context, booking *domain.Booking) error      // New method
 	CreateBooking(ctx context.Context, booking *domain.Booking) error
+	// HasOpenBooking reports whether the user already has a pending, confirmed or
+	// reschedule-requested booking for the vehicle.
+	HasOpenBooking(ctx context.Context, userID, vehicleID int64) (bool, error)
+	// ConfirmBooking reserves the vehicle, confirms the booking, creates its schedule and
+	// declines every competing open booking for the same vehicle, all in one transaction.
+	// The vehicle and booking rows are locked so concurrent confirmations serialize.
+	// If the vehicle is kept at a branch, the viewing takes place there: the staff member
+	// must not belong to another branch, and the branch must have a free viewing slot.
+	ConfirmBooking(ctx context.Context, bookingID int64, schedule *domain.Schedule, competitorReason string) (*domain.Booking, []*domain.Booking, error)
+	// FindStalePendingBookings returns pending bookings whose proposed time is before cutoff.
+	FindStalePendingBookings(ctx context.Context, cutoff time.Time) ([]*domain.Booking, error)
+	// ExpireBooking marks a booking as expired if it is still pending. It reports whether
+	// the booking was expired, so a booking confirmed in the meantime is left alone.
+	ExpireBooking(ctx context.Context, bookingID int64) (bool, error)
+	// DeclineBooking asks the customer to propose another time, if the booking is still
+	// pending. It reports whether the booking was declined, so a booking confirmed in
+	// the meantime is left alone.
+	DeclineBooking(ctx context.Context, bookingID int64, reason string) (bool, error)
+	// CancelBooking cancels a booking and, if it held the vehicle, makes the vehicle available
+	// again. It reports whether the vehicle went from booked back to available.
+	CancelBooking(ctx context.Context, bookingID int64) (*domain.Booking, bool, error)
 }
@@ -5,15 +5,33 @@ package booking
 import (
 	"context"
 	"errors"
+	"log"
 	"mobigo-backend/internal/domain"
 	"mobigo-backend/internal/schedule"
 	"mobigo-backend/internal/vehicle"
 	"time"
 )
 
+// competitorDeclineReason is recorded on pending bookings that lose their vehicle
+// because another customer's booking for it was confirmed first.
+const competitorDeclineReason = "This vehicle has been reserved by another customer."
+
+// Waitlist is what the booking flow needs from the vehicle waitlist.
+type Waitlist interface {
+	CheckBookingAllowed(ctx context.Context, userID, vehicleID int64) error
+	HoldConsumed(ctx context.Context, userID, vehicleID int64) error
+	// VehicleReleased lets the queue move once a booking no longer blocks the vehicle.
+	VehicleReleased(ctx context.Context, vehicleID int64) error
+}
+
 type Service interface {
-	ListAllBookings(ctx context.Context) ([]*domain.Booking, error)
+	// Staff-facing operations.
+	ListBookings(ctx context.Context, filter Filter) ([]*domain.Booking, error)
 	GetBookingDetails(ctx context.Context, id int64) (*domain.Booking, error)
+	// Customer-facing operations; they only ever touch the customer's own bookings.
+	ListCustomerBookings(ctx context.Context, customerID int64) ([]*domain.Booking, error)
+	GetCustomerBooking(ctx context.Context, customerID, bookingID int64) (*domain.Booking, error)
+	CancelCustomerBooking(ctx context.Context, customerID, bookingID int64) (*domain.Booking, error)
 	CreateBooking(ctx context.Context, userID, vehicleID int64, proposedTime time.Time) (*domain.Booking, error)
 	ConfirmSchedule(ctx context.Context, bookingID, staffID int64, notes string) (*domain.Schedule, error)
 	DeclineBooking(ctx context.Context, bookingID int64, reason string) (*domain.Booking, error)
@@ -24,13 +42,22 @@ type service struct {
 	bookingRepo  Repository
 	scheduleRepo schedule.Repository
 	vehicleRepo  vehicle.Repository
+	waitlist     Waitlist
+	availability vehicle.AvailabilityListener
+	branches     vehicle.HomeBranches
 }
 
-func NewService(bookingRepo Repository, scheduleRepo schedule.Repository, vehicleRepo vehicle.Repository) Service {
+// NewService creates the booking service. availability is told when a cancellation
+// frees a vehicle, which includes offering it to the waitlist. branches scopes the
+// staff booking list to the viewer's branch.
+func NewService(bookingRepo Repository, scheduleRepo schedule.Repository, vehicleRepo vehicle.Repository, waitlist Waitlist, availability vehicle.AvailabilityListener, branches vehicle.HomeBranches) Service {
 	return &service{
 		bookingRepo:  bookingRepo,
 		scheduleRepo: scheduleRepo,
 		vehicleRepo:  vehicleRepo,
+		waitlist:     waitlist,
+		availability: availability,
+		branches:     branches,
 	}
 }
 
@@ -43,7 +70,17 @@ func (s *service) CreateBooking(ctx context.Context, userID, vehicleID int64, pr
 		return nil, errors.New("vehicle not found")
 	}
 	if vehicle.Status != domain.VehicleStatusAvailable {
-		return nil, errors.New("vehicle is not available for booking")
+		return nil, errors.New("vehicle is not available for booking, join its waitlist to be notified")
+	}
+	if err := s.waitlist.CheckBookingAllowed(ctx, userID, vehicleID); err != nil {
+		return nil, err
+	}
+	hasOpenBooking, err := s.bookingRepo.HasOpenBooking(ctx, userID, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	if hasOpenBooking {
+		return nil, errors.New("you already have an open booking for this vehicle")
 	}
 
 	newBooking := &domain.Booking{
@@ -52,8 +89,14 @@ func (s *service) CreateBooking(ctx context.Context, userID, vehicleID int64, pr
 		Status:           domain.BookingStatusPending,
 		ProposedDatetime: &proposedTime,
 	}
-	err = s.bookingRepo.CreateBooking(ctx, newBooking)
-	return newBooking, err
+	if err := s.bookingRepo.CreateBooking(ctx, newBooking); err != nil {
+		return nil, err
+	}
+	// Booking the vehicle uses up the customer's waitlist hold, if they had one.
+	if err := s.waitlist.HoldConsumed(ctx, userID, vehicleID); err != nil {
+		log.Printf("BOOKING ERROR: Failed to clear waitlist entry for user %d on vehicle %d: %v", userID, vehicleID, err)
+	}
+	return newBooking, nil
 }
 
 func (s *service) ConfirmSchedule(ctx context.Context, bookingID, staffID int64, notes string) (*domain.Schedule, error) {
@@ -68,22 +111,8 @@ func (s *service) ConfirmSchedule(ctx context.Context, bookingID, staffID int64,
 		return nil, errors.New("customer has not proposed a time for this booking")
 	}
 	if booking.Status != domain.BookingStatusPending {
-		return nil, errors.New("only pending bookings can be confirmed")
-	}
-
-	vehicle, err := s.vehicleRepo.GetVehicleByID(ctx, booking.VehicleID)
-	if err != nil {
-		return nil, err
-	}
-	if vehicle == nil {
-		return nil, errors.New("associated vehicle not found")
+		return nil, ErrBookingNotPending
 	}
-	if vehicle.Status != domain.VehicleStatusAvailable {
-		return nil, errors.New("vehicle is no longer available")
-	}
-
-	vehicle.Status = domain.VehicleStatusBooked
-	booking.Status = domain.BookingStatusConfirmed
 
 	newSchedule := &domain.Schedule{
 		BookingID:           bookingID,
@@ -93,15 +122,14 @@ func (s *service) ConfirmSchedule(ctx context.Context, bookingID, staffID int64,
 		Status:              domain.ScheduleStatusScheduled,
 	}
 
-	// In a real app, this should be a single transaction.
-	if err := s.vehicleRepo.UpdateVehicle(ctx, vehicle); err != nil {
-		return nil, err
-	}
-	if err := s.bookingRepo.UpdateBooking(ctx, booking); err != nil {
+	// The checks above are only a fast path; ConfirmBooking re-validates the booking
+	// and vehicle under row locks so two staff members cannot confirm the same car.
+	confirmed, _, err := s.bookingRepo.ConfirmBooking(ctx, bookingID, newSchedule, competitorDeclineReason)
+	if err != nil {
 		return nil, err
 	}
-	if err := s.scheduleRepo.CreateSchedule(ctx, newSchedule); err != nil {
-		return nil, err
+	if confirmed == nil {
+		return nil, errors.New("booking not found")
 	}
 
 	return newSchedule, nil
@@ -119,13 +147,18 @@ func (s *service) DeclineBooking(ctx context.Context, bookingID int64, reason st
 		return nil, errors.New("only pending bookings can be declined")
 	}
 
+	// The status check is repeated in the update, so a booking confirmed since it was
+	// read is not turned back into a reschedule request.
+	declined, err := s.bookingRepo.DeclineBooking(ctx, bookingID, reason)
+	if err != nil {
+		return nil, err
+	}
+	if !declined {
+		return nil, errors.New("only pending bookings can be declined")
+	}
 	booking.Status = domain.BookingStatusRescheduleRequested
 	booking.ProposedDatetime = nil
 	booking.DeclineReason = &reason
-
-	if err := s.bookingRepo.UpdateBooking(ctx, booking); err != nil {
-		return nil, err
-	}
 	return booking, nil
 }
 
@@ -133,39 +166,71 @@ func (s *service) UpdateBookingStatus(ctx context.Context, bookingID int64, newS
 	if newStatus != domain.BookingStatusCancelled {
 		return nil, errors.New("this action is only for cancelling a booking")
 	}
+	return s.cancelBooking(ctx, bookingID)
+}
 
-	booking, err := s.bookingRepo.GetBookingByID(ctx, bookingID)
-	if err != nil {
-		return nil, err
-	}
-	if booking == nil {
-		return nil, errors.New("booking not found")
-	}
-
-	if booking.Status == domain.BookingStatusConfirmed {
-		vehicle, err := s.vehicleRepo.GetVehicleByID(ctx, booking.VehicleID)
+// ListBookings lists bookings for staff, by default only those at the viewer's home
+// branch or for vehicles not assigned to a branch yet.
+func (s *service) ListBookings(ctx context.Context, filter Filter) ([]*domain.Booking, error) {
+	if filter.BranchID == 0 && !filter.AllBranches && filter.ViewerID != 0 {
+		branchID, err := s.branches.HomeBranchID(ctx, filter.ViewerID)
 		if err != nil {
 			return nil, err
 		}
-		if vehicle != nil && vehicle.Status == domain.VehicleStatusBooked {
-			vehicle.Status = domain.VehicleStatusAvailable
-			if err := s.vehicleRepo.UpdateVehicle(ctx, vehicle); err != nil {
-				return nil, err
-			}
-		}
+		filter.BranchID = branchID
+		filter.IncludeUnassigned = true
 	}
+	return s.bookingRepo.FindBookings(ctx, filter)
+}
 
-	booking.Status = newStatus
-	if err := s.bookingRepo.UpdateBooking(ctx, booking); err != nil {
+func (s *service) GetBookingDetails(ctx context.Context, id int64) (*domain.Booking, error) {
+	return s.bookingRepo.GetBookingByID(ctx, id)
+}
+
+func (s *service) ListCustomerBookings(ctx context.Context, customerID int64) ([]*domain.Booking, error) {
+	return s.bookingRepo.GetBookingsByUser(ctx, customerID)
+}
+
+// GetCustomerBooking returns nil for bookings that belong to someone else,
+// so customers cannot tell other people's booking IDs apart from missing ones.
+func (s *service) GetCustomerBooking(ctx context.Context, customerID, bookingID int64) (*domain.Booking, error) {
+	booking, err := s.bookingRepo.GetBookingByID(ctx, bookingID)
+	if err != nil {
 		return nil, err
 	}
+	if booking == nil || booking.UserID != customerID {
+		return nil, nil
+	}
 	return booking, nil
 }
 
-func (s *service) ListAllBookings(ctx context.Context) ([]*domain.Booking, error) {
-	return s.bookingRepo.GetAllBookings(ctx)
+func (s *service) CancelCustomerBooking(ctx context.Context, customerID, bookingID int64) (*domain.Booking, error) {
+	booking, err := s.GetCustomerBooking(ctx, customerID, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking == nil {
+		return nil, errors.New("booking not found")
+	}
+	return s.cancelBooking(ctx, bookingID)
 }
 
-func (s *service) GetBookingDetails(ctx context.Context, id int64) (*domain.Booking, error) {
-	return s.bookingRepo.GetBookingByID(ctx, id)
+func (s *service) cancelBooking(ctx context.Context, bookingID int64) (*domain.Booking, error) {
+	booking, released, err := s.bookingRepo.CancelBooking(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking == nil {
+		return nil, errors.New("booking not found")
+	}
+	// Favourites are only alerted when the vehicle really became available again; a
+	// cancelled pending booking just stops holding up the waitlist.
+	notify := s.waitlist.VehicleReleased
+	if released {
+		notify = s.availability.VehicleReleased
+	}
+	if err := notify(ctx, booking.VehicleID); err != nil {
+		log.Printf("BOOKING ERROR: Failed to notify waitlist for vehicle %d: %v", booking.VehicleID, err)
+	}
+	return booking, nil
 }
@@ -0,0 +1,63 @@
+package branch
+
+import (
+	"context"
+	"mobigo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type gormRepository struct {
+	db *gorm.DB
+}
+
+func NewGORMRepository(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) Create(ctx context.Context, branch *domain.Branch) error {
+	return r.db.WithContext(ctx).Create(branch).Error
+}
+
+func (r *gormRepository) Update(ctx context.Context, branch *domain.Branch) error {
+	return r.db.WithContext(ctx).Save(branch).Error
+}
+
+func (r *gormRepository) GetByID(ctx context.Context, id int64) (*domain.Branch, error) {
+	return r.first(r.db.WithContext(ctx).Where("id = ?", id))
+}
+
+func (r *gormRepository) GetByName(ctx context.Context, name string) (*domain.Branch, error) {
+	return r.first(r.db.WithContext(ctx).Where("LOWER(name) = LOWER(?)", name))
+}
+
+func (r *gormRepository) List(ctx context.Context) ([]*domain.Branch, error) {
+	var branches []*domain.Branch
+	err := r.db.WithContext(ctx).Order("name asc").Find(&branches).Error
+	return branches, err
+}
+
+func (r *gormRepository) SetUserBranch(ctx context.Context, userID int64, branchID *int64) error {
+	return r.db.WithContext(ctx).Model(&domain.User{}).Where("id = ?", userID).Update("branch_id", branchID).Error
+}
+
+func (r *gormRepository) ListStaff(ctx context.Context, branchID int64) ([]*domain.User, error) {
+	var users []*domain.User
+	err := r.db.WithContext(ctx).
+		Preload("Roles").
+		Where("branch_id = ?", branchID).
+		Order("full_name asc").
+		Find(&users).Error
+	return users, err
+}
+
+func (r *gormRepository) first(query *gorm.DB) (*domain.Branch, error) {
+	var branch domain.Branch
+	if err := query.First(&branch).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &branch, nil
+}
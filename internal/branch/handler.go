@@ -0,0 +1,190 @@
+package branch
+
+import (
+	"encoding/json"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(s Service) *Handler {
+	return &Handler{service: s}
+}
+
+// RegisterRoutes sets up the branch routes. Anyone may look up branches, so the
+// showroom can offer them as a filter; only admins may change them.
+func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler, authz *middleware.Authorizer) {
+	// Registered before the authenticated subrouter so these stay public.
+	router.HandleFunc("/api/branches", h.listBranchesHandler).Methods("GET")
+	router.HandleFunc("/api/branches/{id}", h.getBranchHandler).Methods("GET")
+
+	adminOnly := authz.Require(domain.RoleAdmin)
+	staffOnly := authz.Require(domain.RoleStaff, domain.RoleAdmin)
+
+	r := router.PathPrefix("/api/branches").Subrouter()
+	r.Use(authMiddleware)
+	r.Handle("", adminOnly(http.HandlerFunc(h.createBranchHandler))).Methods("POST")
+	r.Handle("/{id}", adminOnly(http.HandlerFunc(h.updateBranchHandler))).Methods("PUT")
+	r.Handle("/{id}/staff", staffOnly(http.HandlerFunc(h.listStaffHandler))).Methods("GET")
+	r.Handle("/{id}/staff/{userID}", adminOnly(http.HandlerFunc(h.assignStaffHandler))).Methods("PUT")
+	r.Handle("/{id}/staff/{userID}", adminOnly(http.HandlerFunc(h.unassignStaffHandler))).Methods("DELETE")
+}
+
+type branchRequest struct {
+	Name            string `json:"name"`
+	Address         string `json:"address"`
+	PhoneNumber     string `json:"phone_number"`
+	ViewingCapacity int    `json:"viewing_capacity"` // Defaults to 1
+}
+
+func (req branchRequest) input() Input {
+	return Input{
+		Name:            req.Name,
+		Address:         req.Address,
+		PhoneNumber:     req.PhoneNumber,
+		ViewingCapacity: req.ViewingCapacity,
+	}
+}
+
+func (h *Handler) listBranchesHandler(w http.ResponseWriter, r *http.Request) {
+	branches, err := h.service.ListBranches(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to retrieve branches", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(branches)
+}
+
+func (h *Handler) getBranchHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid branch ID", http.StatusBadRequest)
+		return
+	}
+	branch, err := h.service.GetBranch(r.Context(), id)
+	if err != nil {
+		writeBranchError(w, err, "Failed to retrieve branch")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(branch)
+}
+
+func (h *Handler) createBranchHandler(w http.ResponseWriter, r *http.Request) {
+	var req branchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	branch, err := h.service.CreateBranch(r.Context(), req.input())
+	if err != nil {
+		writeBranchError(w, err, "Failed to create branch")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(branch)
+}
+
+func (h *Handler) updateBranchHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid branch ID", http.StatusBadRequest)
+		return
+	}
+	var req branchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	branch, err := h.service.UpdateBranch(r.Context(), id, req.input())
+	if err != nil {
+		writeBranchError(w, err, "Failed to update branch")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(branch)
+}
+
+func (h *Handler) listStaffHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid branch ID", http.StatusBadRequest)
+		return
+	}
+	staff, err := h.service.ListStaff(r.Context(), id)
+	if err != nil {
+		writeBranchError(w, err, "Failed to retrieve branch staff")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(staff)
+}
+
+func (h *Handler) assignStaffHandler(w http.ResponseWriter, r *http.Request) {
+	branchID, userID, ok := parseStaffPath(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.AssignStaff(r.Context(), branchID, userID); err != nil {
+		writeBranchError(w, err, "Failed to assign staff member")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) unassignStaffHandler(w http.ResponseWriter, r *http.Request) {
+	branchID, userID, ok := parseStaffPath(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.UnassignStaff(r.Context(), branchID, userID); err != nil {
+		writeBranchError(w, err, "Failed to unassign staff member")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseStaffPath(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	vars := mux.Vars(r)
+	branchID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid branch ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	userID, err := strconv.ParseInt(vars["userID"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return branchID, userID, true
+}
+
+func writeBranchError(w http.ResponseWriter, err error, fallback string) {
+	switch err.Error() {
+	case "branch not found", "user not found", "staff member is not assigned to this branch":
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case "a branch with this name already exists":
+		http.Error(w, err.Error(), http.StatusConflict)
+	case "branch name is required",
+		"viewing capacity must be at least 1",
+		"only staff and admins can be assigned to a branch":
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
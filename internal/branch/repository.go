@@ -0,0 +1,21 @@
+package branch
+
+import (
+	"context"
+	"mobigo-backend/internal/domain"
+)
+
+// Repository defines the interface for branch data operations.
+type Repository interface {
+	Create(ctx context.Context, branch *domain.Branch) error
+	Update(ctx context.Context, branch *domain.Branch) error
+	GetByID(ctx context.Context, id int64) (*domain.Branch, error)
+	// GetByName finds a branch by name, case-insensitively.
+	GetByName(ctx context.Context, name string) (*domain.Branch, error)
+	// List returns every branch, by name.
+	List(ctx context.Context) ([]*domain.Branch, error)
+	// SetUserBranch sets or, with a nil branchID, clears a user's home branch.
+	SetUserBranch(ctx context.Context, userID int64, branchID *int64) error
+	// ListStaff returns the users whose home branch is branchID, with their roles.
+	ListStaff(ctx context.Context, branchID int64) ([]*domain.User, error)
+}
@@ -0,0 +1,173 @@
+package branch
+
+import (
+	"context"
+	"errors"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/internal/user"
+	"strings"
+)
+
+// Input holds the editable fields of a branch.
+type Input struct {
+	Name            string
+	Address         string
+	PhoneNumber     string
+	ViewingCapacity int
+}
+
+// Service manages the dealership's branches and which staff work at each.
+type Service interface {
+	CreateBranch(ctx context.Context, input Input) (*domain.Branch, error)
+	UpdateBranch(ctx context.Context, id int64, input Input) (*domain.Branch, error)
+	GetBranch(ctx context.Context, id int64) (*domain.Branch, error)
+	ListBranches(ctx context.Context) ([]*domain.Branch, error)
+	// AssignStaff makes the branch the home branch of a staff member or admin,
+	// moving them from their previous branch if they had one.
+	AssignStaff(ctx context.Context, branchID, userID int64) error
+	// UnassignStaff clears the staff member's home branch if it is branchID.
+	UnassignStaff(ctx context.Context, branchID, userID int64) error
+	ListStaff(ctx context.Context, branchID int64) ([]*domain.User, error)
+	// HomeBranchID returns the user's home branch, or 0 if they have none.
+	HomeBranchID(ctx context.Context, userID int64) (int64, error)
+}
+
+type service struct {
+	repo     Repository
+	userRepo user.Repository
+}
+
+func NewService(repo Repository, userRepo user.Repository) Service {
+	return &service{repo: repo, userRepo: userRepo}
+}
+
+func (s *service) CreateBranch(ctx context.Context, input Input) (*domain.Branch, error) {
+	input, err := s.validateInput(ctx, 0, input)
+	if err != nil {
+		return nil, err
+	}
+	branch := &domain.Branch{}
+	applyInput(branch, input)
+	if err := s.repo.Create(ctx, branch); err != nil {
+		return nil, err
+	}
+	return branch, nil
+}
+
+func (s *service) UpdateBranch(ctx context.Context, id int64, input Input) (*domain.Branch, error) {
+	branch, err := s.GetBranch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if input, err = s.validateInput(ctx, id, input); err != nil {
+		return nil, err
+	}
+	applyInput(branch, input)
+	if err := s.repo.Update(ctx, branch); err != nil {
+		return nil, err
+	}
+	return branch, nil
+}
+
+func (s *service) GetBranch(ctx context.Context, id int64) (*domain.Branch, error) {
+	branch, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if branch == nil {
+		return nil, errors.New("branch not found")
+	}
+	return branch, nil
+}
+
+func (s *service) ListBranches(ctx context.Context) ([]*domain.Branch, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *service) AssignStaff(ctx context.Context, branchID, userID int64) error {
+	if _, err := s.GetBranch(ctx, branchID); err != nil {
+		return err
+	}
+	u, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if u == nil {
+		return errors.New("user not found")
+	}
+	if !isStaff(u) {
+		return errors.New("only staff and admins can be assigned to a branch")
+	}
+	return s.repo.SetUserBranch(ctx, userID, &branchID)
+}
+
+func (s *service) UnassignStaff(ctx context.Context, branchID, userID int64) error {
+	u, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if u == nil || u.BranchID == nil || *u.BranchID != branchID {
+		return errors.New("staff member is not assigned to this branch")
+	}
+	return s.repo.SetUserBranch(ctx, userID, nil)
+}
+
+func (s *service) ListStaff(ctx context.Context, branchID int64) ([]*domain.User, error) {
+	if _, err := s.GetBranch(ctx, branchID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListStaff(ctx, branchID)
+}
+
+func (s *service) HomeBranchID(ctx context.Context, userID int64) (int64, error) {
+	u, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if u == nil || u.BranchID == nil {
+		return 0, nil
+	}
+	return *u.BranchID, nil
+}
+
+// validateInput trims the input and checks it. id is the branch being updated, or
+// 0 for a new branch, so a branch does not clash with its own name.
+func (s *service) validateInput(ctx context.Context, id int64, input Input) (Input, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Address = strings.TrimSpace(input.Address)
+	input.PhoneNumber = strings.TrimSpace(input.PhoneNumber)
+	if input.Name == "" {
+		return input, errors.New("branch name is required")
+	}
+	if input.ViewingCapacity == 0 {
+		input.ViewingCapacity = 1
+	}
+	if input.ViewingCapacity < 0 {
+		return input, errors.New("viewing capacity must be at least 1")
+	}
+
+	existing, err := s.repo.GetByName(ctx, input.Name)
+	if err != nil {
+		return input, err
+	}
+	if existing != nil && existing.ID != id {
+		return input, errors.New("a branch with this name already exists")
+	}
+	return input, nil
+}
+
+func applyInput(branch *domain.Branch, input Input) {
+	branch.Name = input.Name
+	branch.Address = input.Address
+	branch.PhoneNumber = input.PhoneNumber
+	branch.ViewingCapacity = input.ViewingCapacity
+}
+
+func isStaff(u *domain.User) bool {
+	for _, role := range u.Roles {
+		if role.Name == domain.RoleStaff || role.Name == domain.RoleAdmin {
+			return true
+		}
+	}
+	return false
+}
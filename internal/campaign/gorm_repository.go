@@ -0,0 +1,102 @@
+package campaign
+
+import (
+	"context"
+	"mobigo-backend/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormRepository struct {
+	db *gorm.DB
+}
+
+func NewGORMRepository(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) Create(ctx context.Context, campaign *domain.DiscountCampaign) error {
+	return r.db.WithContext(ctx).Create(campaign).Error
+}
+
+func (r *gormRepository) GetByID(ctx context.Context, id int64) (*domain.DiscountCampaign, error) {
+	var campaign domain.DiscountCampaign
+	if err := r.db.WithContext(ctx).Preload("Vehicles").First(&campaign, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+func (r *gormRepository) List(ctx context.Context, activeAt *time.Time) ([]*domain.DiscountCampaign, error) {
+	query := r.db.WithContext(ctx).Preload("Vehicles")
+	if activeAt != nil {
+		query = query.Where("starts_at <= ? AND ends_at > ?", *activeAt, *activeAt)
+	}
+	var campaigns []*domain.DiscountCampaign
+	err := query.Order("starts_at desc, id desc").Find(&campaigns).Error
+	return campaigns, err
+}
+
+func (r *gormRepository) MarkAnnounced(ctx context.Context, ids []int64, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&domain.DiscountCampaign{}).
+		Where("id IN ? AND announced_at IS NULL", ids).
+		Update("announced_at", at).Error
+}
+
+func (r *gormRepository) Update(ctx context.Context, campaign *domain.DiscountCampaign) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(campaign).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("campaign_id = ?", campaign.ID).Delete(&domain.DiscountCampaignVehicle{}).Error; err != nil {
+			return err
+		}
+		if len(campaign.Vehicles) == 0 {
+			return nil
+		}
+		for _, v := range campaign.Vehicles {
+			v.CampaignID = campaign.ID
+		}
+		return tx.Create(&campaign.Vehicles).Error
+	})
+}
+
+// Delete soft-deletes the campaign, which ends it immediately.
+func (r *gormRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Delete(&domain.DiscountCampaign{}, id).Error
+}
+
+func (r *gormRepository) LowestPrice(ctx context.Context, campaign *domain.DiscountCampaign) (*float64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.Vehicle{})
+	if len(campaign.Vehicles) > 0 {
+		ids := make([]int64, 0, len(campaign.Vehicles))
+		for _, v := range campaign.Vehicles {
+			ids = append(ids, v.VehicleID)
+		}
+		query = query.Where("id IN ?", ids)
+	} else {
+		// The same criteria as matches, which compares make and model case-insensitively.
+		if campaign.Make != "" {
+			query = query.Where("LOWER(make) = LOWER(?)", campaign.Make)
+		}
+		if campaign.Model != "" {
+			query = query.Where("LOWER(model) = LOWER(?)", campaign.Model)
+		}
+		if campaign.YearMin != 0 {
+			query = query.Where("year >= ?", campaign.YearMin)
+		}
+		if campaign.YearMax != 0 {
+			query = query.Where("year <= ?", campaign.YearMax)
+		}
+	}
+	var price *float64
+	if err := query.Select("MIN(price)").Scan(&price).Error; err != nil {
+		return nil, err
+	}
+	return price, nil
+}
@@ -0,0 +1,163 @@
+package campaign
+
+import (
+	"encoding/json"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/middleware"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(s Service) *Handler {
+	return &Handler{service: s}
+}
+
+// RegisterRoutes sets up the discount campaign routes, which are for staff only.
+func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler, authz *middleware.Authorizer) {
+	r := router.PathPrefix("/api/campaigns").Subrouter()
+	r.Use(authMiddleware, authz.Require(domain.RoleStaff, domain.RoleAdmin))
+
+	r.HandleFunc("", h.listCampaignsHandler).Methods("GET")
+	r.HandleFunc("", h.createCampaignHandler).Methods("POST")
+	r.HandleFunc("/{id}", h.getCampaignHandler).Methods("GET")
+	r.HandleFunc("/{id}", h.updateCampaignHandler).Methods("PUT")
+	r.HandleFunc("/{id}", h.deleteCampaignHandler).Methods("DELETE")
+}
+
+type campaignRequest struct {
+	Name         string              `json:"name"`
+	DiscountType domain.DiscountType `json:"discount_type"`
+	Amount       float64             `json:"amount"`
+	Make         string              `json:"make"`
+	Model        string              `json:"model"`
+	YearMin      int                 `json:"year_min"`
+	YearMax      int                 `json:"year_max"`
+	StartsAt     time.Time           `json:"starts_at"`
+	EndsAt       time.Time           `json:"ends_at"`
+	VehicleIDs   []int64             `json:"vehicle_ids"`
+}
+
+func (req campaignRequest) input() Input {
+	return Input{
+		Name:         req.Name,
+		DiscountType: req.DiscountType,
+		Amount:       req.Amount,
+		Make:         req.Make,
+		Model:        req.Model,
+		YearMin:      req.YearMin,
+		YearMax:      req.YearMax,
+		StartsAt:     req.StartsAt,
+		EndsAt:       req.EndsAt,
+		VehicleIDs:   req.VehicleIDs,
+	}
+}
+
+// listCampaignsHandler lists campaigns. With ?active=true only running campaigns are listed.
+func (h *Handler) listCampaignsHandler(w http.ResponseWriter, r *http.Request) {
+	campaigns, err := h.service.ListCampaigns(r.Context(), r.URL.Query().Get("active") == "true")
+	if err != nil {
+		http.Error(w, "Failed to retrieve campaigns", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(campaigns)
+}
+
+func (h *Handler) createCampaignHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	var req campaignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	campaign, err := h.service.CreateCampaign(r.Context(), userID, req.input())
+	if err != nil {
+		writeCampaignError(w, err, "Failed to create campaign")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(campaign)
+}
+
+func (h *Handler) getCampaignHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+	campaign, err := h.service.GetCampaign(r.Context(), id)
+	if err != nil {
+		writeCampaignError(w, err, "Failed to retrieve campaign")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(campaign)
+}
+
+func (h *Handler) updateCampaignHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+	var req campaignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	campaign, err := h.service.UpdateCampaign(r.Context(), id, req.input())
+	if err != nil {
+		writeCampaignError(w, err, "Failed to update campaign")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(campaign)
+}
+
+func (h *Handler) deleteCampaignHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid campaign ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.service.DeleteCampaign(r.Context(), id); err != nil {
+		writeCampaignError(w, err, "Failed to delete campaign")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeCampaignError(w http.ResponseWriter, err error, fallback string) {
+	switch err.Error() {
+	case "campaign not found":
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case "campaign name is required",
+		"percentage must be more than 0 and less than 100",
+		"discount amount must be positive",
+		"discount amount must be less than the price of every targeted vehicle",
+		"campaign must target vehicles, a make, a model or a year range",
+		"discount type must be percentage or fixed",
+		"campaign must end after it starts",
+		"year_min cannot be after year_max":
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,25 @@
+package campaign
+
+import (
+	"context"
+	"mobigo-backend/internal/domain"
+	"time"
+)
+
+// Repository defines the interface for discount campaign storage. Campaigns are
+// always loaded with their targeted vehicles.
+type Repository interface {
+	Create(ctx context.Context, campaign *domain.DiscountCampaign) error
+	GetByID(ctx context.Context, id int64) (*domain.DiscountCampaign, error)
+	// List returns all campaigns, newest first. With activeAt set, only campaigns
+	// running at that time are returned.
+	List(ctx context.Context, activeAt *time.Time) ([]*domain.DiscountCampaign, error)
+	// Update saves the campaign and replaces its targeted vehicles.
+	Update(ctx context.Context, campaign *domain.DiscountCampaign) error
+	Delete(ctx context.Context, id int64) error
+	// MarkAnnounced records that the price drops of the campaigns were announced.
+	MarkAnnounced(ctx context.Context, ids []int64, at time.Time) error
+	// LowestPrice returns the lowest list price among the vehicles the campaign
+	// targets, or nil if it targets none.
+	LowestPrice(ctx context.Context, campaign *domain.DiscountCampaign) (*float64, error)
+}
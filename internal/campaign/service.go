@@ -0,0 +1,313 @@
+package campaign
+
+import (
+	"context"
+	"errors"
+	"math"
+	"mobigo-backend/internal/domain"
+	"strings"
+	"time"
+)
+
+// Input holds the editable fields of a campaign.
+type Input struct {
+	Name         string
+	DiscountType domain.DiscountType
+	Amount       float64
+	Make         string
+	Model        string
+	YearMin      int
+	YearMax      int
+	StartsAt     time.Time
+	EndsAt       time.Time
+	VehicleIDs   []int64
+}
+
+// PriceDrop is a vehicle whose effective price a newly started or edited campaign lowered.
+type PriceDrop struct {
+	VehicleID int64
+	OldPrice  float64
+	NewPrice  float64
+}
+
+type Service interface {
+	CreateCampaign(ctx context.Context, createdByID int64, input Input) (*domain.DiscountCampaign, error)
+	GetCampaign(ctx context.Context, id int64) (*domain.DiscountCampaign, error)
+	ListCampaigns(ctx context.Context, activeOnly bool) ([]*domain.DiscountCampaign, error)
+	UpdateCampaign(ctx context.Context, id int64, input Input) (*domain.DiscountCampaign, error)
+	DeleteCampaign(ctx context.Context, id int64) error
+	// ApplyDiscounts sets EffectivePrice and DiscountCampaignID on each vehicle from
+	// the campaigns running now. When several match, the lowest price wins.
+	ApplyDiscounts(ctx context.Context, vehicles []*domain.Vehicle) error
+	// AnnouncePriceDrops finds the running campaigns whose price drops have not been
+	// announced since they started or were last edited, and marks them announced. For
+	// each of the vehicles they lower, it returns the price under the campaigns that
+	// were already announced and the price now.
+	AnnouncePriceDrops(ctx context.Context, vehicles []*domain.Vehicle) ([]PriceDrop, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) CreateCampaign(ctx context.Context, createdByID int64, input Input) (*domain.DiscountCampaign, error) {
+	if err := validateInput(input); err != nil {
+		return nil, err
+	}
+	campaign := &domain.DiscountCampaign{CreatedByID: createdByID}
+	applyInput(campaign, input)
+	if err := s.checkNotFree(ctx, campaign); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, campaign); err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+func (s *service) GetCampaign(ctx context.Context, id int64) (*domain.DiscountCampaign, error) {
+	campaign, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if campaign == nil {
+		return nil, errors.New("campaign not found")
+	}
+	fillVehicleIDs(campaign)
+	return campaign, nil
+}
+
+func (s *service) ListCampaigns(ctx context.Context, activeOnly bool) ([]*domain.DiscountCampaign, error) {
+	var activeAt *time.Time
+	if activeOnly {
+		now := time.Now()
+		activeAt = &now
+	}
+	campaigns, err := s.repo.List(ctx, activeAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range campaigns {
+		fillVehicleIDs(c)
+	}
+	return campaigns, nil
+}
+
+func (s *service) UpdateCampaign(ctx context.Context, id int64, input Input) (*domain.DiscountCampaign, error) {
+	if err := validateInput(input); err != nil {
+		return nil, err
+	}
+	campaign, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if campaign == nil {
+		return nil, errors.New("campaign not found")
+	}
+	applyInput(campaign, input)
+	if err := s.checkNotFree(ctx, campaign); err != nil {
+		return nil, err
+	}
+	// The edited campaign may lower prices further, so it is announced again.
+	campaign.AnnouncedAt = nil
+	if err := s.repo.Update(ctx, campaign); err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+func (s *service) DeleteCampaign(ctx context.Context, id int64) error {
+	campaign, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if campaign == nil {
+		return errors.New("campaign not found")
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *service) ApplyDiscounts(ctx context.Context, vehicles []*domain.Vehicle) error {
+	if len(vehicles) == 0 {
+		return nil
+	}
+	now := time.Now()
+	campaigns, err := s.repo.List(ctx, &now)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range vehicles {
+		v.EffectivePrice, v.DiscountCampaignID = bestPrice(campaigns, v)
+	}
+	return nil
+}
+
+func (s *service) AnnouncePriceDrops(ctx context.Context, vehicles []*domain.Vehicle) ([]PriceDrop, error) {
+	now := time.Now()
+	running, err := s.repo.List(ctx, &now)
+	if err != nil {
+		return nil, err
+	}
+	var announced []*domain.DiscountCampaign
+	var pending []int64
+	for _, c := range running {
+		if c.AnnouncedAt != nil {
+			announced = append(announced, c)
+		} else {
+			pending = append(pending, c.ID)
+		}
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	var drops []PriceDrop
+	for _, v := range vehicles {
+		oldPrice, _ := bestPrice(announced, v)
+		newPrice, _ := bestPrice(running, v)
+		if newPrice < oldPrice {
+			drops = append(drops, PriceDrop{VehicleID: v.ID, OldPrice: oldPrice, NewPrice: newPrice})
+		}
+	}
+	if err := s.repo.MarkAnnounced(ctx, pending, now); err != nil {
+		return nil, err
+	}
+	return drops, nil
+}
+
+// bestPrice returns the lowest price the campaigns give the vehicle and the campaign
+// giving it, or the list price and nil if none of them applies.
+func bestPrice(campaigns []*domain.DiscountCampaign, v *domain.Vehicle) (float64, *int64) {
+	best := v.Price
+	var campaignID *int64
+	for _, c := range campaigns {
+		if !matches(c, v) {
+			continue
+		}
+		// A fixed discount can outgrow a vehicle whose price was cut after the
+		// campaign was set up; it is ignored rather than giving the car away.
+		if price := discountedPrice(c, v.Price); price > 0 && price < best {
+			best = price
+			id := c.ID
+			campaignID = &id
+		}
+	}
+	return best, campaignID
+}
+
+// matches reports whether a campaign targets the vehicle.
+func matches(c *domain.DiscountCampaign, v *domain.Vehicle) bool {
+	if len(c.Vehicles) > 0 {
+		for _, cv := range c.Vehicles {
+			if cv.VehicleID == v.ID {
+				return true
+			}
+		}
+		return false
+	}
+	if c.Make != "" && !strings.EqualFold(c.Make, v.Make) {
+		return false
+	}
+	if c.Model != "" && !strings.EqualFold(c.Model, v.Model) {
+		return false
+	}
+	if c.YearMin != 0 && v.Year < c.YearMin {
+		return false
+	}
+	if c.YearMax != 0 && v.Year > c.YearMax {
+		return false
+	}
+	return true
+}
+
+// discountedPrice applies a campaign to a list price, rounded to cents and never below zero.
+func discountedPrice(c *domain.DiscountCampaign, price float64) float64 {
+	switch c.DiscountType {
+	case domain.DiscountTypePercentage:
+		price = price * (100 - c.Amount) / 100
+	case domain.DiscountTypeFixed:
+		price -= c.Amount
+	}
+	return math.Max(0, math.Round(price*100)/100)
+}
+
+func validateInput(input Input) error {
+	if strings.TrimSpace(input.Name) == "" {
+		return errors.New("campaign name is required")
+	}
+	switch input.DiscountType {
+	case domain.DiscountTypePercentage:
+		if input.Amount <= 0 || input.Amount >= 100 {
+			return errors.New("percentage must be more than 0 and less than 100")
+		}
+	case domain.DiscountTypeFixed:
+		if input.Amount <= 0 {
+			return errors.New("discount amount must be positive")
+		}
+	default:
+		return errors.New("discount type must be percentage or fixed")
+	}
+	if input.StartsAt.IsZero() || !input.EndsAt.After(input.StartsAt) {
+		return errors.New("campaign must end after it starts")
+	}
+	if input.YearMin != 0 && input.YearMax != 0 && input.YearMin > input.YearMax {
+		return errors.New("year_min cannot be after year_max")
+	}
+	// A campaign with no criteria would discount the whole stock.
+	if len(input.VehicleIDs) == 0 && strings.TrimSpace(input.Make) == "" && strings.TrimSpace(input.Model) == "" &&
+		input.YearMin == 0 && input.YearMax == 0 {
+		return errors.New("campaign must target vehicles, a make, a model or a year range")
+	}
+	return nil
+}
+
+// checkNotFree refuses a fixed discount that would bring any targeted vehicle to zero.
+func (s *service) checkNotFree(ctx context.Context, c *domain.DiscountCampaign) error {
+	if c.DiscountType != domain.DiscountTypeFixed {
+		return nil
+	}
+	lowest, err := s.repo.LowestPrice(ctx, c)
+	if err != nil {
+		return err
+	}
+	if lowest != nil && discountedPrice(c, *lowest) <= 0 {
+		return errors.New("discount amount must be less than the price of every targeted vehicle")
+	}
+	return nil
+}
+
+func applyInput(c *domain.DiscountCampaign, input Input) {
+	c.Name = strings.TrimSpace(input.Name)
+	c.DiscountType = input.DiscountType
+	c.Amount = input.Amount
+	c.Make = strings.TrimSpace(input.Make)
+	c.Model = strings.TrimSpace(input.Model)
+	c.YearMin = input.YearMin
+	c.YearMax = input.YearMax
+	c.StartsAt = input.StartsAt
+	c.EndsAt = input.EndsAt
+
+	seen := make(map[int64]bool, len(input.VehicleIDs))
+	c.Vehicles = nil
+	c.VehicleIDs = nil
+	for _, id := range input.VehicleIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		c.Vehicles = append(c.Vehicles, &domain.DiscountCampaignVehicle{VehicleID: id})
+		c.VehicleIDs = append(c.VehicleIDs, id)
+	}
+}
+
+func fillVehicleIDs(c *domain.DiscountCampaign) {
+	c.VehicleIDs = make([]int64, 0, len(c.Vehicles))
+	for _, v := range c.Vehicles {
+		c.VehicleIDs = append(c.VehicleIDs, v.VehicleID)
+	}
+}
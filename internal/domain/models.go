@@ -8,6 +8,14 @@ import (
 	"time"
 )
 
+// --- Role Names ---
+// These match the rows seeded into the roles table.
+const (
+	RoleCustomer = "customer"
+	RoleStaff    = "staff"
+	RoleAdmin    = "admin"
+)
+
 // --- Status Enums ---
 type VehicleStatus string
 
@@ -18,6 +26,51 @@ const (
 	VehicleStatusOnInstallment VehicleStatus = "on_installment" // THE NEW STATUS
 )
 
+// --- Vehicle Specification Enums ---
+type Transmission string
+
+const (
+	TransmissionManual    Transmission = "manual"
+	TransmissionAutomatic Transmission = "automatic"
+	TransmissionCVT       Transmission = "cvt"
+)
+
+type FuelType string
+
+const (
+	FuelTypePetrol   FuelType = "petrol"
+	FuelTypeDiesel   FuelType = "diesel"
+	FuelTypeHybrid   FuelType = "hybrid"
+	FuelTypeElectric FuelType = "electric"
+)
+
+type BodyType string
+
+const (
+	BodyTypeSedan     BodyType = "sedan"
+	BodyTypeHatchback BodyType = "hatchback"
+	BodyTypeSUV       BodyType = "suv"
+	BodyTypeMPV       BodyType = "mpv"
+	BodyTypePickup    BodyType = "pickup"
+	BodyTypeCoupe     BodyType = "coupe"
+	BodyTypeVan       BodyType = "van"
+)
+
+type VehicleCondition string
+
+const (
+	VehicleConditionNew  VehicleCondition = "new"
+	VehicleConditionUsed VehicleCondition = "used"
+)
+
+type ServiceHistory string
+
+const (
+	ServiceHistoryFull    ServiceHistory = "full"    // Every scheduled service documented
+	ServiceHistoryPartial ServiceHistory = "partial" // Some service records missing
+	ServiceHistoryNone    ServiceHistory = "none"
+)
+
 type BookingStatus string
 
 const (
@@ -26,6 +79,8 @@ const (
 	BookingStatusCancelled           BookingStatus = "cancelled"
 	BookingStatusCompleted           BookingStatus = "completed"
 	BookingStatusRescheduleRequested BookingStatus = "reschedule_requested" // THE NEW STATUS
+	BookingStatusDeclined            BookingStatus = "declined"             // Lost the vehicle to another confirmed booking
+	BookingStatusExpired             BookingStatus = "expired"              // Proposed time passed without confirmation
 )
 
 type ScheduleStatus string
@@ -63,20 +118,94 @@ const (
 	InstallmentStatusFailed  InstallmentStatus = "failed"
 )
 
+type WaitlistStatus string
+
+const (
+	WaitlistStatusWaiting   WaitlistStatus = "waiting"
+	WaitlistStatusHolding   WaitlistStatus = "holding" // Customer has a time-limited hold to book
+	WaitlistStatusFulfilled WaitlistStatus = "fulfilled"
+	WaitlistStatusExpired   WaitlistStatus = "expired"
+	WaitlistStatusCancelled WaitlistStatus = "cancelled"
+)
+
+type NotificationType string
+
+const (
+	NotificationTypeWaitlistHold        NotificationType = "waitlist_hold"
+	NotificationTypeWaitlistHoldExpired NotificationType = "waitlist_hold_expired"
+	NotificationTypeBookingExpired      NotificationType = "booking_expired"
+	NotificationTypeDocumentReviewed    NotificationType = "document_reviewed"
+	NotificationTypeTradeInAppraised    NotificationType = "trade_in_appraised"
+	NotificationTypeFavouritePriceDrop  NotificationType = "favourite_price_drop"
+	NotificationTypeFavouriteAvailable  NotificationType = "favourite_available"
+)
+
+type DocumentType string
+
+const (
+	DocumentTypeKTP     DocumentType = "ktp"     // National identity card
+	DocumentTypeKK      DocumentType = "kk"      // Family card
+	DocumentTypePayslip DocumentType = "payslip" // Proof of income
+)
+
+type DocumentStatus string
+
+const (
+	DocumentStatusPending  DocumentStatus = "pending"
+	DocumentStatusApproved DocumentStatus = "approved"
+	DocumentStatusRejected DocumentStatus = "rejected"
+)
+
+type TradeInStatus string
+
+const (
+	TradeInStatusSubmitted TradeInStatus = "submitted" // Awaiting appraisal
+	TradeInStatusAppraised TradeInStatus = "appraised" // Offer made, awaiting the customer's decision
+	TradeInStatusAccepted  TradeInStatus = "accepted"  // Credit can be applied to an agreement
+	TradeInStatusDeclined  TradeInStatus = "declined"
+	TradeInStatusApplied   TradeInStatus = "applied" // Credit used on an agreement
+	TradeInStatusWithdrawn TradeInStatus = "withdrawn"
+)
+
+type DiscountType string
+
+const (
+	DiscountTypePercentage DiscountType = "percentage" // Amount is a percentage of the list price
+	DiscountTypeFixed      DiscountType = "fixed"      // Amount is subtracted from the list price
+)
+
+type AuditAction string
+
+const (
+	AuditActionLoginLocked       AuditAction = "login_locked"
+	AuditActionLoginUnlocked     AuditAction = "login_unlocked"
+	AuditActionTwoFactorRequired AuditAction = "two_factor_required"
+	AuditActionTwoFactorOptional AuditAction = "two_factor_optional"
+	AuditActionTwoFactorReset    AuditAction = "two_factor_reset"
+)
+
 // --- Main Models ---
 
 type User struct {
-	ID             int64            `gorm:"primaryKey;autoIncrement" json:"id"`
-	FullName       string           `gorm:"not null" json:"full_name"`
-	Email          string           `gorm:"unique;not null" json:"email"`
-	PasswordHash   string           `gorm:"not null" json:"-"`
-	PhoneNumber    string           `json:"phone_number"`
-	Address        string           `json:"address"`
-	CreatedAt      time.Time        `json:"created_at"`
-	UpdatedAt      time.Time        `json:"updated_at"`
-	DeletedAt      gorm.DeletedAt   `gorm:"index" json:"-"`
-	Roles          []*Role          `gorm:"many2many:user_roles;" json:"roles,omitempty"`
-	PaymentMethods []*PaymentMethod `gorm:"foreignKey:UserID" json:"payment_methods,omitempty"`
+	ID                  int64            `gorm:"primaryKey;autoIncrement" json:"id"`
+	FullName            string           `gorm:"not null" json:"full_name"`
+	Email               string           `gorm:"unique;not null" json:"email"`
+	PasswordHash        string           `gorm:"not null" json:"-"`
+	PhoneNumber         string           `json:"phone_number"`
+	Address             string           `json:"address"`
+	VerifiedAt          *time.Time       `json:"verified_at,omitempty"`           // When the user proved they own Email
+	DeactivatedAt       *time.Time       `json:"deactivated_at,omitempty"`        // Deactivated users cannot log in
+	DeletionRequestedAt *time.Time       `json:"deletion_requested_at,omitempty"` // Set by the user; an admin carries out the deletion
+	TOTPSecret          string           `gorm:"column:totp_secret" json:"-"`     // Set on setup, in use once TOTPEnabledAt is set
+	TOTPEnabledAt       *time.Time       `gorm:"column:totp_enabled_at" json:"two_factor_enabled_at,omitempty"`
+	TOTPLastStep        int64            `gorm:"column:totp_last_step;not null;default:0" json:"-"` // Last accepted code's time step, so a code works only once
+	TwoFactorRequired   bool             `gorm:"not null;default:false" json:"two_factor_required"` // Set by an admin; the user must enroll before logging in
+	BranchID            *int64           `gorm:"index" json:"branch_id,omitempty"`                  // Home branch of a staff member
+	CreatedAt           time.Time        `json:"created_at"`
+	UpdatedAt           time.Time        `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt   `gorm:"index" json:"-"`
+	Roles               []*Role          `gorm:"many2many:user_roles;" json:"roles,omitempty"`
+	PaymentMethods      []*PaymentMethod `gorm:"foreignKey:UserID" json:"payment_methods,omitempty"`
 }
 
 type Role struct {
@@ -87,6 +216,83 @@ type Role struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// StaffInvitation lets an admin invite someone to register as staff or admin.
+// Only the SHA-256 hash of the invitation token is stored.
+type StaffInvitation struct {
+	ID          int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Email       string     `gorm:"not null;index" json:"email"`
+	RoleID      int64      `gorm:"not null" json:"role_id"`
+	TokenHash   string     `gorm:"unique;not null" json:"-"`
+	InvitedByID int64      `gorm:"not null" json:"invited_by_id"`
+	ExpiresAt   time.Time  `gorm:"not null" json:"expires_at"`
+	AcceptedAt  *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Role        *Role      `gorm:"foreignKey:RoleID" json:"role,omitempty"`
+}
+
+// UserSession is one login on one device. The client holds a refresh token for it,
+// which is rotated on every use; only the SHA-256 hashes are stored. Access tokens
+// carry the session ID so that revoking the session revokes them too.
+type UserSession struct {
+	ID                int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID            int64      `gorm:"not null;index" json:"user_id"`
+	RefreshTokenHash  string     `gorm:"unique;not null" json:"-"`
+	PreviousTokenHash string     `gorm:"index" json:"-"` // The token rotated out last; presenting it again means it was stolen
+	ExpiresAt         time.Time  `gorm:"not null" json:"expires_at"`
+	LastUsedAt        *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt         *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// PasswordResetToken is a single-use token emailed to a user who forgot their password.
+// Only the SHA-256 hash of the token is stored.
+type PasswordResetToken struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    int64      `gorm:"not null;index" json:"user_id"`
+	TokenHash string     `gorm:"unique;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// EmailVerificationToken is a single-use token emailed to a new customer to confirm
+// their address. Only the SHA-256 hash of the token is stored.
+type EmailVerificationToken struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    int64      `gorm:"not null;index" json:"user_id"`
+	TokenHash string     `gorm:"unique;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// LoginThrottle counts recent failed logins for one key, either an account
+// ("account:<email>") or a client address ("ip:<address>").
+type LoginThrottle struct {
+	ID             int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Key            string     `gorm:"column:throttle_key;unique;not null" json:"key"`
+	FailedAttempts int        `gorm:"not null;default:0" json:"failed_attempts"`
+	LastFailedAt   time.Time  `json:"last_failed_at"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// RecoveryCode is a single-use code that stands in for a TOTP code when the user
+// has lost their authenticator. Only the SHA-256 hash of the code is stored.
+type RecoveryCode struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    int64      `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
 type PaymentMethod struct {
 	ID            int64          `gorm:"primaryKey;autoIncrement" json:"id"`
 	UserID        int64          `gorm:"not null" json:"user_id"`
@@ -112,6 +318,82 @@ type Vehicle struct {
 	UpdatedAt   time.Time       `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt  `gorm:"index" json:"-"`
 	Images      []*VehicleImage `gorm:"foreignKey:VehicleID" json:"images,omitempty"`
+
+	VehicleSpecs
+	Features []*VehicleFeature `gorm:"foreignKey:VehicleID" json:"features,omitempty"`
+
+	// EffectivePrice is Price after the best active discount campaign. It is computed
+	// when vehicles are read and is not stored.
+	EffectivePrice     float64 `gorm:"-" json:"effective_price"`
+	DiscountCampaignID *int64  `gorm:"-" json:"discount_campaign_id,omitempty"`
+
+	// BranchID is where the vehicle is kept. It only changes through a VehicleTransfer.
+	BranchID *int64  `gorm:"index" json:"branch_id,omitempty"`
+	Branch   *Branch `gorm:"foreignKey:BranchID" json:"branch,omitempty"`
+}
+
+// VehicleSpecs holds the structured specification of a vehicle. It is embedded in
+// Vehicle, so its fields are columns of the vehicles table and appear flat in JSON.
+type VehicleSpecs struct {
+	Mileage        int              `gorm:"not null;default:0" json:"mileage"`         // Kilometres
+	EngineCapacity int              `gorm:"not null;default:0" json:"engine_capacity"` // cc; 0 for electric vehicles
+	Transmission   Transmission     `gorm:"type:varchar(20)" json:"transmission,omitempty"`
+	FuelType       FuelType         `gorm:"type:varchar(20)" json:"fuel_type,omitempty"`
+	BodyType       BodyType         `gorm:"type:varchar(20)" json:"body_type,omitempty"`
+	Color          string           `gorm:"type:varchar(50)" json:"color,omitempty"`
+	Seats          int              `gorm:"not null;default:0" json:"seats"`
+	PlateRegion    string           `gorm:"type:varchar(10)" json:"plate_region,omitempty"` // Registration area code, e.g. "B" for Jakarta
+	Condition      VehicleCondition `gorm:"column:vehicle_condition;type:varchar(10);not null;default:'used'" json:"condition"`
+	ServiceHistory ServiceHistory   `gorm:"type:varchar(20)" json:"service_history,omitempty"`
+}
+
+// VehicleFeature is a feature tag on a vehicle, e.g. "sunroof" or "rear camera".
+// Names are stored lower-case so they can be matched exactly.
+type VehicleFeature struct {
+	ID        int64  `gorm:"primaryKey;autoIncrement" json:"-"`
+	VehicleID int64  `gorm:"not null;uniqueIndex:idx_vehicle_feature" json:"-"`
+	Name      string `gorm:"type:varchar(50);not null;uniqueIndex:idx_vehicle_feature;index" json:"name"`
+}
+
+// VehiclePriceChange records one change to a vehicle's list price.
+type VehiclePriceChange struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	VehicleID   int64     `gorm:"not null;index" json:"vehicle_id"`
+	OldPrice    float64   `gorm:"type:decimal(15,2);not null" json:"old_price"`
+	NewPrice    float64   `gorm:"type:decimal(15,2);not null" json:"new_price"`
+	ChangedByID int64     `gorm:"not null" json:"changed_by_id"`
+	Reason      string    `gorm:"not null" json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// DiscountCampaign lowers the price of matching vehicles between StartsAt and EndsAt.
+// A campaign with VehicleIDs applies to exactly those vehicles; otherwise it applies
+// to every vehicle matching its make, model and year range, where blank fields match
+// anything.
+type DiscountCampaign struct {
+	ID           int64                      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name         string                     `gorm:"not null" json:"name"`
+	DiscountType DiscountType               `gorm:"type:varchar(20);not null" json:"discount_type"`
+	Amount       float64                    `gorm:"type:decimal(15,2);not null" json:"amount"`
+	Make         string                     `json:"make,omitempty"`
+	Model        string                     `json:"model,omitempty"`
+	YearMin      int                        `gorm:"not null;default:0" json:"year_min,omitempty"`
+	YearMax      int                        `gorm:"not null;default:0" json:"year_max,omitempty"`
+	StartsAt     time.Time                  `gorm:"not null;index" json:"starts_at"`
+	EndsAt       time.Time                  `gorm:"not null;index" json:"ends_at"`
+	AnnouncedAt  *time.Time                 `json:"-"` // When its price drops were announced to favourites; cleared on edit
+	CreatedByID  int64                      `gorm:"not null" json:"created_by_id"`
+	CreatedAt    time.Time                  `json:"created_at"`
+	UpdatedAt    time.Time                  `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt             `gorm:"index" json:"-"`
+	Vehicles     []*DiscountCampaignVehicle `gorm:"foreignKey:CampaignID" json:"-"`
+	VehicleIDs   []int64                    `gorm:"-" json:"vehicle_ids,omitempty"`
+}
+
+// DiscountCampaignVehicle targets a campaign at one specific vehicle.
+type DiscountCampaignVehicle struct {
+	CampaignID int64 `gorm:"primaryKey"`
+	VehicleID  int64 `gorm:"primaryKey"`
 }
 
 type VehicleImage struct {
@@ -146,10 +428,22 @@ type Schedule struct {
 	AppointmentDatetime time.Time      `gorm:"not null" json:"appointment_datetime"`
 	Notes               string         `json:"notes"`
 	Status              ScheduleStatus `gorm:"type:varchar(50);not null;default:'scheduled'" json:"status"`
+	BranchID            *int64         `gorm:"index" json:"branch_id,omitempty"` // Where the viewing takes place
 	CreatedAt           time.Time      `json:"created_at"`
 	UpdatedAt           time.Time      `json:"updated_at"`
 	DeletedAt           gorm.DeletedAt `gorm:"index" json:"-"`
 	User                *User          `gorm:"foreignKey:UserID" json:"staff_member,omitempty"`
+	Booking             *Booking       `gorm:"foreignKey:BookingID" json:"booking,omitempty"`
+}
+
+// CalendarFeedToken grants read-only access to a staff member's iCalendar feed.
+// Only the SHA-256 hash of the token is stored; the raw token lives in the feed URL.
+type CalendarFeedToken struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    int64     `gorm:"unique;not null" json:"user_id"`
+	TokenHash string    `gorm:"unique;not null" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type Agreement struct {
@@ -161,6 +455,8 @@ type Agreement struct {
 	Terms         string         `json:"terms"`
 	SignedByUser  bool           `gorm:"default:false" json:"signed_by_user"`
 	SignedByStaff bool           `gorm:"default:false" json:"signed_by_staff"`
+	TradeInID     *int64         `gorm:"uniqueIndex" json:"trade_in_id,omitempty"`                     // A trade-in can only be used once
+	TradeInCredit float64        `gorm:"type:decimal(15,2);not null;default:0" json:"trade_in_credit"` // Deducted from the amount the customer pays
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
@@ -193,3 +489,133 @@ type Installment struct {
 	UpdatedAt     time.Time         `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt    `gorm:"index" json:"-"`
 }
+
+type WaitlistEntry struct {
+	ID            int64          `gorm:"primaryKey;autoIncrement" json:"id"`
+	VehicleID     int64          `gorm:"not null;index" json:"vehicle_id"`
+	UserID        int64          `gorm:"not null;index" json:"user_id"`
+	Status        WaitlistStatus `gorm:"type:varchar(50);not null;default:'waiting'" json:"status"`
+	HoldExpiresAt *time.Time     `json:"hold_expires_at,omitempty"`
+	Position      int64          `gorm:"-" json:"position,omitempty"` // 1-based queue position, computed on read
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	Vehicle       *Vehicle       `gorm:"foreignKey:VehicleID" json:"vehicle,omitempty"`
+	User          *User          `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// Branch is one of the dealership's showrooms. Vehicles, staff and viewings belong
+// to a branch.
+type Branch struct {
+	ID          int64  `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name        string `gorm:"type:varchar(100);unique;not null" json:"name"`
+	Address     string `json:"address"`
+	PhoneNumber string `gorm:"type:varchar(30)" json:"phone_number,omitempty"`
+	// ViewingCapacity is how many viewings the branch can host at the same time.
+	ViewingCapacity int       `gorm:"not null;default:1" json:"viewing_capacity"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// VehicleTransfer records a vehicle being moved from one branch to another.
+// FromBranchID is nil when the vehicle had not been assigned a branch yet.
+type VehicleTransfer struct {
+	ID              int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	VehicleID       int64     `gorm:"not null;index" json:"vehicle_id"`
+	FromBranchID    *int64    `json:"from_branch_id,omitempty"`
+	ToBranchID      int64     `gorm:"not null" json:"to_branch_id"`
+	TransferredByID int64     `gorm:"not null" json:"transferred_by_id"`
+	Reason          string    `json:"reason,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	FromBranch      *Branch   `gorm:"foreignKey:FromBranchID" json:"from_branch,omitempty"`
+	ToBranch        *Branch   `gorm:"foreignKey:ToBranchID" json:"to_branch,omitempty"`
+}
+
+// FavouriteVehicle is a vehicle a customer has saved. They are notified when its
+// price drops or it becomes available again.
+type FavouriteVehicle struct {
+	ID        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    int64     `gorm:"not null;uniqueIndex:idx_favourite_vehicles_user_vehicle" json:"user_id"`
+	VehicleID int64     `gorm:"not null;uniqueIndex:idx_favourite_vehicles_user_vehicle;index" json:"vehicle_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Vehicle   *Vehicle  `gorm:"foreignKey:VehicleID" json:"vehicle,omitempty"`
+}
+
+type Notification struct {
+	ID        int64            `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    int64            `gorm:"not null;index" json:"user_id"`
+	Type      NotificationType `gorm:"type:varchar(50);not null" json:"type"`
+	Title     string           `gorm:"not null" json:"title"`
+	Message   string           `json:"message"`
+	ReadAt    *time.Time       `json:"read_at,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// AuditLog records a security-relevant event. ActorID is nil for events the
+// system triggers itself, such as an automatic lockout.
+type AuditLog struct {
+	ID         int64       `gorm:"primaryKey;autoIncrement" json:"id"`
+	ActorID    *int64      `gorm:"index" json:"actor_id,omitempty"`
+	Action     AuditAction `gorm:"type:varchar(50);not null;index" json:"action"`
+	TargetType string      `gorm:"type:varchar(50)" json:"target_type,omitempty"`
+	TargetID   *int64      `json:"target_id,omitempty"`
+	IPAddress  string      `gorm:"type:varchar(45)" json:"ip_address,omitempty"`
+	Details    string      `json:"details,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// CustomerDocument is an identity or income document a customer uploads for
+// installment financing. The file is kept outside the public uploads directory
+// and only served to its owner and staff.
+type CustomerDocument struct {
+	ID              int64          `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID          int64          `gorm:"not null;index" json:"user_id"`
+	Type            DocumentType   `gorm:"type:varchar(50);not null" json:"type"`
+	FileName        string         `gorm:"not null" json:"file_name"` // Name of the file as uploaded
+	StoragePath     string         `gorm:"not null" json:"-"`
+	ContentType     string         `gorm:"type:varchar(100);not null" json:"content_type"`
+	SizeBytes       int64          `gorm:"not null" json:"size_bytes"`
+	Status          DocumentStatus `gorm:"type:varchar(50);not null;default:'pending'" json:"status"`
+	RejectionReason string         `json:"rejection_reason,omitempty"`
+	ReviewedByID    *int64         `json:"reviewed_by_id,omitempty"`
+	ReviewedAt      *time.Time     `json:"reviewed_at,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	User            *User          `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// TradeIn is a customer's own vehicle offered as part payment. Staff appraise it and,
+// once the customer accepts the offer, its value is credited on an agreement.
+type TradeIn struct {
+	ID             int64           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID         int64           `gorm:"not null;index" json:"user_id"`
+	Make           string          `gorm:"not null" json:"make"`
+	Model          string          `gorm:"not null" json:"model"`
+	Year           int             `gorm:"not null" json:"year"`
+	Mileage        int             `gorm:"not null;default:0" json:"mileage"` // Kilometres
+	PlateNumber    string          `gorm:"type:varchar(20)" json:"plate_number,omitempty"`
+	VIN            string          `gorm:"type:varchar(17)" json:"vin,omitempty"`
+	Description    string          `json:"description,omitempty"` // The customer's account of its condition
+	Status         TradeInStatus   `gorm:"type:varchar(20);not null;default:'submitted';index" json:"status"`
+	AppraisedValue float64         `gorm:"type:decimal(15,2);not null;default:0" json:"appraised_value"`
+	AppraisalNotes string          `json:"appraisal_notes,omitempty"`
+	AppraisedByID  *int64          `json:"appraised_by_id,omitempty"`
+	AppraisedAt    *time.Time      `json:"appraised_at,omitempty"`
+	OfferExpiresAt *time.Time      `json:"offer_expires_at,omitempty"`
+	AgreementID    *int64          `json:"agreement_id,omitempty"` // Set once the credit is applied
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	Photos         []*TradeInPhoto `gorm:"foreignKey:TradeInID" json:"photos,omitempty"`
+	User           *User           `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// TradeInPhoto is a photo of a trade-in vehicle. Like KYC documents, the files are
+// kept outside the public uploads directory.
+type TradeInPhoto struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	TradeInID   int64     `gorm:"not null;index" json:"trade_in_id"`
+	FileName    string    `gorm:"not null" json:"file_name"`
+	StoragePath string    `gorm:"not null" json:"-"`
+	ContentType string    `gorm:"type:varchar(100);not null" json:"content_type"`
+	CreatedAt   time.Time `json:"created_at"`
+}
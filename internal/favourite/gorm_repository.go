@@ -0,0 +1,66 @@
+package favourite
+
+import (
+	"context"
+	"mobigo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type gormRepository struct {
+	db *gorm.DB
+}
+
+func NewGORMRepository(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) Create(ctx context.Context, favourite *domain.FavouriteVehicle) error {
+	return r.db.WithContext(ctx).Create(favourite).Error
+}
+
+func (r *gormRepository) Delete(ctx context.Context, userID, vehicleID int64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("user_id = ? AND vehicle_id = ?", userID, vehicleID).
+		Delete(&domain.FavouriteVehicle{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *gormRepository) Get(ctx context.Context, userID, vehicleID int64) (*domain.FavouriteVehicle, error) {
+	var favourite domain.FavouriteVehicle
+	err := r.db.WithContext(ctx).Where("user_id = ? AND vehicle_id = ?", userID, vehicleID).First(&favourite).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &favourite, nil
+}
+
+func (r *gormRepository) ListByUser(ctx context.Context, userID int64) ([]*domain.FavouriteVehicle, error) {
+	var favourites []*domain.FavouriteVehicle
+	err := r.db.WithContext(ctx).
+		Preload("Vehicle").
+		Preload("Vehicle.Images").
+		Where("user_id = ?", userID).
+		Order("created_at desc, id desc").
+		Find(&favourites).Error
+	return favourites, err
+}
+
+func (r *gormRepository) VehicleIDs(ctx context.Context) ([]int64, error) {
+	var vehicleIDs []int64
+	err := r.db.WithContext(ctx).Model(&domain.FavouriteVehicle{}).
+		Distinct("vehicle_id").
+		Pluck("vehicle_id", &vehicleIDs).Error
+	return vehicleIDs, err
+}
+
+func (r *gormRepository) UserIDsForVehicle(ctx context.Context, vehicleID int64) ([]int64, error) {
+	var userIDs []int64
+	err := r.db.WithContext(ctx).Model(&domain.FavouriteVehicle{}).
+		Where("vehicle_id = ?", vehicleID).
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
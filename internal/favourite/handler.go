@@ -0,0 +1,105 @@
+package favourite
+
+import (
+	"encoding/json"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(s Service) *Handler {
+	return &Handler{service: s}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler, authz *middleware.Authorizer) {
+	customerOnly := authz.Require(domain.RoleCustomer)
+
+	// Like the waitlist, a favourite hangs off the vehicle it belongs to.
+	r := router.PathPrefix("/api/vehicles/{vehicleID}/favourite").Subrouter()
+	r.Use(authMiddleware, customerOnly)
+	r.HandleFunc("", h.addFavouriteHandler).Methods("POST")
+	r.HandleFunc("", h.removeFavouriteHandler).Methods("DELETE")
+
+	me := router.PathPrefix("/api/favourites").Subrouter()
+	me.Use(authMiddleware, customerOnly)
+	me.HandleFunc("/me", h.listMyFavouritesHandler).Methods("GET")
+}
+
+func (h *Handler) addFavouriteHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	vehicleID, err := strconv.ParseInt(mux.Vars(r)["vehicleID"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid vehicle ID", http.StatusBadRequest)
+		return
+	}
+
+	favourite, err := h.service.Add(r.Context(), userID, vehicleID)
+	if err != nil {
+		switch err.Error() {
+		case "vehicle not found":
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case "vehicle is already in your favourites":
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to add favourite", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(favourite)
+}
+
+func (h *Handler) removeFavouriteHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	vehicleID, err := strconv.ParseInt(mux.Vars(r)["vehicleID"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid vehicle ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Remove(r.Context(), userID, vehicleID); err != nil {
+		if err.Error() == "favourite not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to remove favourite", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) listMyFavouritesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+
+	favourites, err := h.service.ListForUser(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve favourites", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(favourites)
+}
@@ -0,0 +1,21 @@
+package favourite
+
+import (
+	"context"
+	"mobigo-backend/internal/domain"
+)
+
+// Repository defines the interface for favourite vehicle data operations.
+type Repository interface {
+	Create(ctx context.Context, favourite *domain.FavouriteVehicle) error
+	// Delete removes the user's favourite for a vehicle and reports whether there was one.
+	Delete(ctx context.Context, userID, vehicleID int64) (bool, error)
+	// Get returns the user's favourite for a vehicle, if any.
+	Get(ctx context.Context, userID, vehicleID int64) (*domain.FavouriteVehicle, error)
+	// ListByUser returns a user's favourites with their vehicles, most recently saved first.
+	ListByUser(ctx context.Context, userID int64) ([]*domain.FavouriteVehicle, error)
+	// VehicleIDs returns every vehicle at least one user has saved.
+	VehicleIDs(ctx context.Context) ([]int64, error)
+	// UserIDsForVehicle returns the users who saved a vehicle.
+	UserIDsForVehicle(ctx context.Context, vehicleID int64) ([]int64, error)
+}
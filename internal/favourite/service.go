@@ -0,0 +1,177 @@
+package favourite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mobigo-backend/internal/campaign"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/internal/vehicle"
+)
+
+// Notifier is what favourites need to alert customers about their saved vehicles.
+type Notifier interface {
+	Notify(ctx context.Context, userID int64, notificationType domain.NotificationType, title, message string) error
+}
+
+// Campaigns prices vehicles with the running discount campaigns and reports the price
+// drops of campaigns that started or changed.
+type Campaigns interface {
+	vehicle.DiscountApplier
+	AnnouncePriceDrops(ctx context.Context, vehicles []*domain.Vehicle) ([]campaign.PriceDrop, error)
+}
+
+// Service manages the vehicles customers have saved. Customers are notified when
+// the price of a saved vehicle is lowered, by staff or by a discount campaign, or
+// when it becomes available again.
+type Service interface {
+	Add(ctx context.Context, userID, vehicleID int64) (*domain.FavouriteVehicle, error)
+	Remove(ctx context.Context, userID, vehicleID int64) error
+	// ListForUser returns the user's favourites with the vehicles' effective prices.
+	ListForUser(ctx context.Context, userID int64) ([]*domain.FavouriteVehicle, error)
+	// PriceDropped alerts everyone who saved the vehicle, unless it has been sold.
+	PriceDropped(ctx context.Context, vehicleID int64, oldPrice, newPrice float64) error
+	// VehicleReleased alerts everyone who saved the vehicle if it is available.
+	VehicleReleased(ctx context.Context, vehicleID int64) error
+	// AnnounceCampaigns alerts customers whose saved vehicles became cheaper because a
+	// discount campaign started or was edited since the last run.
+	AnnounceCampaigns(ctx context.Context) error
+}
+
+type service struct {
+	repo        Repository
+	vehicleRepo vehicle.Repository
+	campaigns   Campaigns
+	notifier    Notifier
+}
+
+func NewService(repo Repository, vehicleRepo vehicle.Repository, campaigns Campaigns, notifier Notifier) Service {
+	return &service{
+		repo:        repo,
+		vehicleRepo: vehicleRepo,
+		campaigns:   campaigns,
+		notifier:    notifier,
+	}
+}
+
+func (s *service) Add(ctx context.Context, userID, vehicleID int64) (*domain.FavouriteVehicle, error) {
+	v, err := s.vehicleRepo.GetVehicleByID(ctx, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, errors.New("vehicle not found")
+	}
+
+	existing, err := s.repo.Get(ctx, userID, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("vehicle is already in your favourites")
+	}
+
+	favourite := &domain.FavouriteVehicle{UserID: userID, VehicleID: vehicleID}
+	if err := s.repo.Create(ctx, favourite); err != nil {
+		return nil, err
+	}
+	return favourite, nil
+}
+
+func (s *service) Remove(ctx context.Context, userID, vehicleID int64) error {
+	removed, err := s.repo.Delete(ctx, userID, vehicleID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return errors.New("favourite not found")
+	}
+	return nil
+}
+
+func (s *service) ListForUser(ctx context.Context, userID int64) ([]*domain.FavouriteVehicle, error) {
+	favourites, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	vehicles := make([]*domain.Vehicle, 0, len(favourites))
+	for _, f := range favourites {
+		if f.Vehicle != nil {
+			vehicles = append(vehicles, f.Vehicle)
+		}
+	}
+	if err := s.campaigns.ApplyDiscounts(ctx, vehicles); err != nil {
+		return nil, err
+	}
+	return favourites, nil
+}
+
+func (s *service) PriceDropped(ctx context.Context, vehicleID int64, oldPrice, newPrice float64) error {
+	v, err := s.vehicleRepo.GetVehicleByID(ctx, vehicleID)
+	if err != nil {
+		return err
+	}
+	if v == nil || v.Status == domain.VehicleStatusSold || v.Status == domain.VehicleStatusOnInstallment {
+		return nil
+	}
+
+	message := fmt.Sprintf("The price of the %s you saved has dropped from %.2f to %.2f.", describe(v), oldPrice, newPrice)
+	return s.notifyAll(ctx, vehicleID, domain.NotificationTypeFavouritePriceDrop, "A vehicle you saved is cheaper", message)
+}
+
+func (s *service) VehicleReleased(ctx context.Context, vehicleID int64) error {
+	v, err := s.vehicleRepo.GetVehicleByID(ctx, vehicleID)
+	if err != nil {
+		return err
+	}
+	if v == nil || v.Status != domain.VehicleStatusAvailable {
+		return nil
+	}
+
+	message := fmt.Sprintf("The %s you saved is available again. Book a viewing before someone else does.", describe(v))
+	return s.notifyAll(ctx, vehicleID, domain.NotificationTypeFavouriteAvailable, "A vehicle you saved is available", message)
+}
+
+func (s *service) AnnounceCampaigns(ctx context.Context) error {
+	vehicleIDs, err := s.repo.VehicleIDs(ctx)
+	if err != nil {
+		return err
+	}
+	var vehicles []*domain.Vehicle
+	if len(vehicleIDs) > 0 {
+		if vehicles, err = s.vehicleRepo.GetVehiclesByIDs(ctx, vehicleIDs); err != nil {
+			return err
+		}
+	}
+	// Campaigns are marked announced even when no saved vehicle matches them.
+	drops, err := s.campaigns.AnnouncePriceDrops(ctx, vehicles)
+	if err != nil {
+		return err
+	}
+	for _, d := range drops {
+		if err := s.PriceDropped(ctx, d.VehicleID, d.OldPrice, d.NewPrice); err != nil {
+			log.Printf("FAVOURITE ERROR: Failed to announce campaign price drop for vehicle %d: %v", d.VehicleID, err)
+		}
+	}
+	return nil
+}
+
+// notifyAll sends the notification to everyone who saved the vehicle. A failed
+// notification is logged and does not stop the rest from being sent.
+func (s *service) notifyAll(ctx context.Context, vehicleID int64, notificationType domain.NotificationType, title, message string) error {
+	userIDs, err := s.repo.UserIDsForVehicle(ctx, vehicleID)
+	if err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		if err := s.notifier.Notify(ctx, userID, notificationType, title, message); err != nil {
+			log.Printf("FAVOURITE ERROR: Failed to notify user %d: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+func describe(v *domain.Vehicle) string {
+	return fmt.Sprintf("%d %s %s", v.Year, v.Make, v.Model)
+}
@@ -0,0 +1,72 @@
+package kyc
+
+import (
+	"context"
+	"mobigo-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type gormRepository struct {
+	db *gorm.DB
+}
+
+func NewGORMRepository(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) Create(ctx context.Context, document *domain.CustomerDocument) error {
+	return r.db.WithContext(ctx).Create(document).Error
+}
+
+func (r *gormRepository) GetByID(ctx context.Context, id int64) (*domain.CustomerDocument, error) {
+	var document domain.CustomerDocument
+	err := r.db.WithContext(ctx).First(&document, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &document, nil
+}
+
+func (r *gormRepository) ListByUser(ctx context.Context, userID int64) ([]*domain.CustomerDocument, error) {
+	var documents []*domain.CustomerDocument
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at desc").
+		Find(&documents).Error
+	return documents, err
+}
+
+func (r *gormRepository) Find(ctx context.Context, filter Filter) ([]*domain.CustomerDocument, error) {
+	query := r.db.WithContext(ctx).Preload("User").Order("created_at asc")
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+
+	var documents []*domain.CustomerDocument
+	err := query.Find(&documents).Error
+	return documents, err
+}
+
+func (r *gormRepository) Update(ctx context.Context, document *domain.CustomerDocument) error {
+	return r.db.WithContext(ctx).Omit("User").Save(document).Error
+}
+
+func (r *gormRepository) Delete(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Delete(&domain.CustomerDocument{}, id).Error
+}
+
+func (r *gormRepository) ApprovedTypes(ctx context.Context, userID int64) ([]domain.DocumentType, error) {
+	var types []domain.DocumentType
+	err := r.db.WithContext(ctx).Model(&domain.CustomerDocument{}).
+		Where("user_id = ? AND status = ?", userID, domain.DocumentStatusApproved).
+		Distinct().
+		Pluck("type", &types).Error
+	return types, err
+}
@@ -0,0 +1,249 @@
+package kyc
+
+import (
+	"encoding/json"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(s Service) *Handler {
+	return &Handler{service: s}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler, authz *middleware.Authorizer) {
+	customerOnly := authz.Require(domain.RoleCustomer)
+	staffOnly := authz.Require(domain.RoleStaff, domain.RoleAdmin)
+
+	r := router.PathPrefix("/api/documents").Subrouter()
+	r.Use(authMiddleware)
+
+	// Customer routes: always scoped to the user in the JWT.
+	r.Handle("", customerOnly(http.HandlerFunc(h.uploadDocumentHandler))).Methods("POST")
+	r.Handle("/me", customerOnly(http.HandlerFunc(h.listMyDocumentsHandler))).Methods("GET")
+	r.Handle("/me/{id:[0-9]+}/file", customerOnly(http.HandlerFunc(h.downloadMyDocumentHandler))).Methods("GET")
+	r.Handle("/me/{id:[0-9]+}", customerOnly(http.HandlerFunc(h.deleteMyDocumentHandler))).Methods("DELETE")
+
+	// Staff routes: the review queue.
+	r.Handle("", staffOnly(http.HandlerFunc(h.listDocumentsHandler))).Methods("GET")
+	r.Handle("/{id:[0-9]+}/file", staffOnly(http.HandlerFunc(h.downloadDocumentHandler))).Methods("GET")
+	r.Handle("/{id:[0-9]+}/review", staffOnly(http.HandlerFunc(h.reviewDocumentHandler))).Methods("PUT")
+}
+
+type myDocumentsResponse struct {
+	Data    []*domain.CustomerDocument `json:"data"`
+	Missing []domain.DocumentType      `json:"missing"` // Still needed for installment financing
+}
+
+type reviewDocumentRequest struct {
+	Status string `json:"status"` // "approved" or "rejected"
+	Reason string `json:"reason"` // Required when rejecting
+}
+
+// uploadDocumentHandler takes a multipart form with a "type" field and a "file" field.
+func (h *Handler) uploadDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxFileSize+1<<20) // Room for the form fields
+	if err := r.ParseMultipartForm(MaxFileSize); err != nil {
+		http.Error(w, "Could not parse multipart form", http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Invalid document file", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	document, err := h.service.Upload(r.Context(), userID, domain.DocumentType(r.FormValue("type")), header.Filename, file)
+	if err != nil {
+		switch err.Error() {
+		case "unknown document type", "documents must be JPEG, PNG or PDF files", "document is larger than 10 MB":
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to save document", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(document)
+}
+
+func (h *Handler) listMyDocumentsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+
+	documents, err := h.service.ListForUser(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve documents", http.StatusInternalServerError)
+		return
+	}
+	missing, err := h.service.MissingDocuments(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve documents", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(myDocumentsResponse{Data: documents, Missing: missing})
+}
+
+func (h *Handler) downloadMyDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	documentID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	document, err := h.service.GetForUser(r.Context(), userID, documentID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve document", http.StatusInternalServerError)
+		return
+	}
+	h.serveFile(w, r, document)
+}
+
+func (h *Handler) deleteMyDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	documentID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	if err := h.service.DeleteForUser(r.Context(), userID, documentID); err != nil {
+		switch err.Error() {
+		case "document not found":
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case "approved documents cannot be deleted":
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to delete document", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listDocumentsHandler lists documents for review. Query params: status, user_id.
+func (h *Handler) listDocumentsHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := Filter{Status: domain.DocumentStatus(q.Get("status"))}
+	if userID := q.Get("user_id"); userID != "" {
+		id, err := strconv.ParseInt(userID, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid user_id", http.StatusBadRequest)
+			return
+		}
+		filter.UserID = id
+	}
+
+	documents, err := h.service.List(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "Failed to retrieve documents", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(documents)
+}
+
+func (h *Handler) downloadDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	documentID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	document, err := h.service.GetDocument(r.Context(), documentID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve document", http.StatusInternalServerError)
+		return
+	}
+	h.serveFile(w, r, document)
+}
+
+func (h *Handler) reviewDocumentHandler(w http.ResponseWriter, r *http.Request) {
+	staffID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	documentID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	var req reviewDocumentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var approve bool
+	switch domain.DocumentStatus(req.Status) {
+	case domain.DocumentStatusApproved:
+		approve = true
+	case domain.DocumentStatusRejected:
+		approve = false
+	default:
+		http.Error(w, "Status must be 'approved' or 'rejected'", http.StatusBadRequest)
+		return
+	}
+
+	document, err := h.service.Review(r.Context(), staffID, documentID, approve, req.Reason)
+	if err != nil {
+		switch err.Error() {
+		case "document not found":
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case "only pending documents can be reviewed":
+			http.Error(w, err.Error(), http.StatusConflict)
+		case "a reason is required to reject a document":
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to review document", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(document)
+}
+
+// serveFile streams a document's file. Files are never served from a public path.
+func (h *Handler) serveFile(w http.ResponseWriter, r *http.Request, document *domain.CustomerDocument) {
+	if document == nil {
+		http.Error(w, "Document not found", http.StatusNotFound)
+		return
+	}
+	file, err := h.service.OpenFile(document)
+	if err != nil {
+		http.Error(w, "Document file is unavailable", http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, "Document file is unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", document.ContentType)
+	w.Header().Set("Content-Disposition", "inline; filename="+strconv.Quote(document.FileName))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, "", info.ModTime(), file)
+}
@@ -0,0 +1,27 @@
+package kyc
+
+import (
+	"context"
+	"mobigo-backend/internal/domain"
+)
+
+// Filter narrows down the staff review list. Zero values are ignored.
+type Filter struct {
+	Status domain.DocumentStatus
+	UserID int64
+}
+
+// Repository defines the interface for customer document storage.
+type Repository interface {
+	Create(ctx context.Context, document *domain.CustomerDocument) error
+	GetByID(ctx context.Context, id int64) (*domain.CustomerDocument, error)
+	// ListByUser returns a customer's documents, newest first.
+	ListByUser(ctx context.Context, userID int64) ([]*domain.CustomerDocument, error)
+	// Find returns documents matching the filter with their owners loaded, oldest first
+	// so the review queue is worked through in order.
+	Find(ctx context.Context, filter Filter) ([]*domain.CustomerDocument, error)
+	Update(ctx context.Context, document *domain.CustomerDocument) error
+	Delete(ctx context.Context, id int64) error
+	// ApprovedTypes returns the document types the customer has at least one approved document for.
+	ApprovedTypes(ctx context.Context, userID int64) ([]domain.DocumentType, error)
+}
@@ -0,0 +1,216 @@
+package kyc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/privatefile"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// MaxFileSize is the largest document that can be uploaded.
+const MaxFileSize = 10 << 20 // 10 MB
+
+// InstallmentRequirements are the documents a customer needs approved before an
+// installment plan can be generated for them.
+var InstallmentRequirements = []domain.DocumentType{
+	domain.DocumentTypeKTP,
+	domain.DocumentTypeKK,
+	domain.DocumentTypePayslip,
+}
+
+// allowedContentTypes are the file formats accepted, detected from the file content.
+var allowedContentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+// Notifier delivers in-app notifications to customers.
+type Notifier interface {
+	Notify(ctx context.Context, userID int64, notificationType domain.NotificationType, title, message string) error
+}
+
+// Service manages the identity and income documents customers submit for financing.
+type Service interface {
+	// Upload stores a new document for the customer, pending review.
+	Upload(ctx context.Context, userID int64, docType domain.DocumentType, fileName string, content io.Reader) (*domain.CustomerDocument, error)
+	ListForUser(ctx context.Context, userID int64) ([]*domain.CustomerDocument, error)
+	// GetForUser returns one of the customer's own documents, or nil if it is not theirs.
+	GetForUser(ctx context.Context, userID, documentID int64) (*domain.CustomerDocument, error)
+	// DeleteForUser removes a customer's document. Approved documents cannot be removed.
+	DeleteForUser(ctx context.Context, userID, documentID int64) error
+	List(ctx context.Context, filter Filter) ([]*domain.CustomerDocument, error)
+	GetDocument(ctx context.Context, documentID int64) (*domain.CustomerDocument, error)
+	// Review approves or rejects a pending document. A rejection needs a reason.
+	Review(ctx context.Context, staffID, documentID int64, approve bool, reason string) (*domain.CustomerDocument, error)
+	// OpenFile opens a document's stored file for reading.
+	OpenFile(document *domain.CustomerDocument) (*os.File, error)
+	// MissingDocuments returns the installment requirements the customer has no approved document for.
+	MissingDocuments(ctx context.Context, userID int64) ([]domain.DocumentType, error)
+}
+
+type service struct {
+	repo     Repository
+	notifier Notifier
+	files    privatefile.Store
+}
+
+// NewService creates a document service that keeps files in storageDir, which must
+// not be publicly served.
+func NewService(repo Repository, notifier Notifier, storageDir string) Service {
+	files := privatefile.Store{Dir: storageDir, Types: allowedContentTypes, MaxSize: MaxFileSize}
+	return &service{repo: repo, notifier: notifier, files: files}
+}
+
+func (s *service) Upload(ctx context.Context, userID int64, docType domain.DocumentType, fileName string, content io.Reader) (*domain.CustomerDocument, error) {
+	if !isKnownType(docType) {
+		return nil, errors.New("unknown document type")
+	}
+
+	// Stored under a random name; the uploaded name is only kept for display.
+	file, err := s.files.Save(content)
+	if err != nil {
+		switch {
+		case errors.Is(err, privatefile.ErrUnsupportedType):
+			return nil, errors.New("documents must be JPEG, PNG or PDF files")
+		case errors.Is(err, privatefile.ErrTooLarge):
+			return nil, errors.New("document is larger than 10 MB")
+		}
+		return nil, err
+	}
+
+	document := &domain.CustomerDocument{
+		UserID:      userID,
+		Type:        docType,
+		FileName:    filepath.Base(fileName),
+		StoragePath: file.Path,
+		ContentType: file.ContentType,
+		SizeBytes:   file.Size,
+		Status:      domain.DocumentStatusPending,
+	}
+	if err := s.repo.Create(ctx, document); err != nil {
+		os.Remove(file.Path)
+		return nil, err
+	}
+	return document, nil
+}
+
+func (s *service) ListForUser(ctx context.Context, userID int64) ([]*domain.CustomerDocument, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+func (s *service) GetForUser(ctx context.Context, userID, documentID int64) (*domain.CustomerDocument, error) {
+	document, err := s.repo.GetByID(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if document == nil || document.UserID != userID {
+		return nil, nil
+	}
+	return document, nil
+}
+
+func (s *service) DeleteForUser(ctx context.Context, userID, documentID int64) error {
+	document, err := s.GetForUser(ctx, userID, documentID)
+	if err != nil {
+		return err
+	}
+	if document == nil {
+		return errors.New("document not found")
+	}
+	if document.Status == domain.DocumentStatusApproved {
+		return errors.New("approved documents cannot be deleted")
+	}
+	if err := s.repo.Delete(ctx, documentID); err != nil {
+		return err
+	}
+	if err := os.Remove(document.StoragePath); err != nil && !os.IsNotExist(err) {
+		log.Printf("KYC ERROR: Failed to remove file for document %d: %v", documentID, err)
+	}
+	return nil
+}
+
+func (s *service) List(ctx context.Context, filter Filter) ([]*domain.CustomerDocument, error) {
+	return s.repo.Find(ctx, filter)
+}
+
+func (s *service) GetDocument(ctx context.Context, documentID int64) (*domain.CustomerDocument, error) {
+	return s.repo.GetByID(ctx, documentID)
+}
+
+func (s *service) Review(ctx context.Context, staffID, documentID int64, approve bool, reason string) (*domain.CustomerDocument, error) {
+	document, err := s.repo.GetByID(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if document == nil {
+		return nil, errors.New("document not found")
+	}
+	if document.Status != domain.DocumentStatusPending {
+		return nil, errors.New("only pending documents can be reviewed")
+	}
+	if !approve && reason == "" {
+		return nil, errors.New("a reason is required to reject a document")
+	}
+
+	now := time.Now()
+	document.ReviewedByID = &staffID
+	document.ReviewedAt = &now
+	if approve {
+		document.Status = domain.DocumentStatusApproved
+		document.RejectionReason = ""
+	} else {
+		document.Status = domain.DocumentStatusRejected
+		document.RejectionReason = reason
+	}
+	if err := s.repo.Update(ctx, document); err != nil {
+		return nil, err
+	}
+
+	title := fmt.Sprintf("Your %s document was approved", document.Type)
+	message := "Thank you, it has been accepted for your financing application."
+	if !approve {
+		title = fmt.Sprintf("Your %s document was rejected", document.Type)
+		message = fmt.Sprintf("Reason: %s. Please upload a new one.", reason)
+	}
+	if err := s.notifier.Notify(ctx, document.UserID, domain.NotificationTypeDocumentReviewed, title, message); err != nil {
+		log.Printf("KYC ERROR: Failed to notify user %d: %v", document.UserID, err)
+	}
+	return document, nil
+}
+
+func (s *service) OpenFile(document *domain.CustomerDocument) (*os.File, error) {
+	return os.Open(document.StoragePath)
+}
+
+func (s *service) MissingDocuments(ctx context.Context, userID int64) ([]domain.DocumentType, error) {
+	approved, err := s.repo.ApprovedTypes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	have := make(map[domain.DocumentType]bool, len(approved))
+	for _, t := range approved {
+		have[t] = true
+	}
+	var missing []domain.DocumentType
+	for _, t := range InstallmentRequirements {
+		if !have[t] {
+			missing = append(missing, t)
+		}
+	}
+	return missing, nil
+}
+
+func isKnownType(docType domain.DocumentType) bool {
+	switch docType {
+	case domain.DocumentTypeKTP, domain.DocumentTypeKK, domain.DocumentTypePayslip:
+		return true
+	}
+	return false
+}
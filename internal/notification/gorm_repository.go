@@ -0,0 +1,37 @@
+package notification
+
+import (
+	"context"
+	"mobigo-backend/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type gormRepository struct {
+	db *gorm.DB
+}
+
+func NewGORMRepository(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) Create(ctx context.Context, notification *domain.Notification) error {
+	return r.db.WithContext(ctx).Create(notification).Error
+}
+
+func (r *gormRepository) ListByUser(ctx context.Context, userID int64) ([]*domain.Notification, error) {
+	var notifications []*domain.Notification
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at desc").
+		Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *gormRepository) MarkRead(ctx context.Context, id, userID int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,68 @@
+package notification
+
+import (
+	"encoding/json"
+	"mobigo-backend/pkg/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(s Service) *Handler {
+	return &Handler{service: s}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler) {
+	r := router.PathPrefix("/api/notifications").Subrouter()
+	r.Use(authMiddleware)
+
+	r.HandleFunc("", h.listNotificationsHandler).Methods("GET")
+	r.HandleFunc("/{id}/read", h.markReadHandler).Methods("PUT")
+}
+
+func (h *Handler) listNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+
+	notifications, err := h.service.ListForUser(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve notifications", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(notifications)
+}
+
+func (h *Handler) markReadHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid notification ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.MarkRead(r.Context(), id, userID); err != nil {
+		if err.Error() == "notification not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to update notification", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
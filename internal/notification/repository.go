@@ -0,0 +1,16 @@
+package notification
+
+import (
+	"context"
+	"mobigo-backend/internal/domain"
+)
+
+// Repository defines the interface for notification data operations.
+type Repository interface {
+	Create(ctx context.Context, notification *domain.Notification) error
+	// ListByUser returns a user's notifications, newest first.
+	ListByUser(ctx context.Context, userID int64) ([]*domain.Notification, error)
+	// MarkRead marks a notification as read. It reports false if the
+	// notification does not exist or belongs to another user.
+	MarkRead(ctx context.Context, id, userID int64) (bool, error)
+}
@@ -0,0 +1,46 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"mobigo-backend/internal/domain"
+)
+
+// Service delivers in-app notifications to users.
+type Service interface {
+	Notify(ctx context.Context, userID int64, notificationType domain.NotificationType, title, message string) error
+	ListForUser(ctx context.Context, userID int64) ([]*domain.Notification, error)
+	MarkRead(ctx context.Context, id, userID int64) error
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) Notify(ctx context.Context, userID int64, notificationType domain.NotificationType, title, message string) error {
+	return s.repo.Create(ctx, &domain.Notification{
+		UserID:  userID,
+		Type:    notificationType,
+		Title:   title,
+		Message: message,
+	})
+}
+
+func (s *service) ListForUser(ctx context.Context, userID int64) ([]*domain.Notification, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+func (s *service) MarkRead(ctx context.Context, id, userID int64) error {
+	found, err := s.repo.MarkRead(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("notification not found")
+	}
+	return nil
+}
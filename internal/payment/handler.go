@@ -2,6 +2,8 @@ package payment
 
 import (
 	"encoding/json"
+	"errors"
+	"mobigo-backend/internal/domain"
 	"mobigo-backend/pkg/middleware"
 	"net/http"
 	"strconv"
@@ -17,12 +19,17 @@ func NewHandler(s Service) *Handler {
 	return &Handler{service: s}
 }
 
-func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler) {
+func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler, authz *middleware.Authorizer) {
 	r := router.PathPrefix("/api/payments").Subrouter()
 	r.Use(authMiddleware)
 
-	r.HandleFunc("/generate-plan", h.generatePlanHandler).Methods("POST")
-	r.HandleFunc("/{id}/initiate", h.initiatePaymentHandler).Methods("POST")
+	staffOnly := authz.Require(domain.RoleStaff, domain.RoleAdmin)
+	customerOnly := authz.Require(domain.RoleCustomer)
+	verified := authz.RequireVerified()
+
+	r.Handle("/generate-plan", staffOnly(http.HandlerFunc(h.generatePlanHandler))).Methods("POST")
+	// Only customers with a verified email address may pay.
+	r.Handle("/{id}/initiate", customerOnly(verified(http.HandlerFunc(h.initiatePaymentHandler)))).Methods("POST")
 }
 
 // generatePlanRequest uses float64 to match the service and domain layers.
@@ -48,6 +55,10 @@ func (h *Handler) generatePlanHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.service.GenerateInstallmentPlan(r.Context(), serviceReq); err != nil {
+		if errors.Is(err, ErrDocumentsNotApproved) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -12,6 +12,15 @@ import (
 	"time"
 )
 
+// ErrDocumentsNotApproved is returned when an installment plan is requested for a
+// customer whose required KYC documents are not all approved yet.
+var ErrDocumentsNotApproved = errors.New("customer's required documents have not been approved")
+
+// DocumentChecker reports which required KYC documents a customer still lacks approval for.
+type DocumentChecker interface {
+	MissingDocuments(ctx context.Context, userID int64) ([]domain.DocumentType, error)
+}
+
 type Service interface {
 	GenerateInstallmentPlan(ctx context.Context, req GeneratePlanRequest) error
 	InitiatePayment(ctx context.Context, paymentID int64, customerID int64) (*domain.Payment, error)
@@ -25,15 +34,17 @@ type service struct {
 	vehicleRepo     vehicle.Repository
 	agreementRepo   agreement.Repository
 	bookingRepo     booking.Repository
+	documents       DocumentChecker
 }
 
-func NewService(paymentRepo Repository, installmentRepo installment.Repository, vehicleRepo vehicle.Repository, agreementRepo agreement.Repository, bookingRepo booking.Repository) Service {
+func NewService(paymentRepo Repository, installmentRepo installment.Repository, vehicleRepo vehicle.Repository, agreementRepo agreement.Repository, bookingRepo booking.Repository, documents DocumentChecker) Service {
 	return &service{
 		paymentRepo:     paymentRepo,
 		installmentRepo: installmentRepo,
 		vehicleRepo:     vehicleRepo,
 		agreementRepo:   agreementRepo,
 		bookingRepo:     bookingRepo,
+		documents:       documents,
 	}
 }
 
@@ -50,7 +61,7 @@ func (s *service) CreateFullPaymentForAgreement(ctx context.Context, agreementID
 	// Create a single payment record
 	fullPayment := &domain.Payment{
 		AgreementID:   agreementID,
-		Amount:        agreement.FinalPrice,
+		Amount:        agreement.FinalPrice - agreement.TradeInCredit,
 		PaymentMethod: "Full Payment",
 		Status:        domain.PaymentStatusPending,
 	}
@@ -93,6 +104,10 @@ func (s *service) GenerateInstallmentPlan(ctx context.Context, req GeneratePlanR
 	if len(existingPayments) > 0 {
 		return errors.New("an installment plan already exists for this agreement")
 	}
+	// A trade-in credit counts towards the down payment; the customer pays the rest in cash.
+	if req.DownPayment < agreement.TradeInCredit {
+		req.DownPayment = agreement.TradeInCredit
+	}
 	if req.DownPayment >= agreement.FinalPrice {
 		return errors.New("down payment must be less than the total price")
 	}
@@ -106,15 +121,27 @@ func (s *service) GenerateInstallmentPlan(ctx context.Context, req GeneratePlanR
 		return errors.New("vehicle not found for this agreement")
 	}
 
-	dpPayment := &domain.Payment{
-		AgreementID:   req.AgreementID,
-		Amount:        req.DownPayment,
-		PaymentMethod: "Down Payment",
-		Status:        domain.PaymentStatusPending,
-	}
-	if err := s.paymentRepo.CreatePayment(ctx, dpPayment); err != nil {
+	// Financing is only extended to customers whose identity and income are verified.
+	missing, err := s.documents.MissingDocuments(ctx, booking.UserID)
+	if err != nil {
 		return err
 	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing %v", ErrDocumentsNotApproved, missing)
+	}
+
+	// No cash down payment is due when the trade-in covers it all.
+	if cashDown := req.DownPayment - agreement.TradeInCredit; cashDown > 0 || agreement.TradeInCredit == 0 {
+		dpPayment := &domain.Payment{
+			AgreementID:   req.AgreementID,
+			Amount:        cashDown,
+			PaymentMethod: "Down Payment",
+			Status:        domain.PaymentStatusPending,
+		}
+		if err := s.paymentRepo.CreatePayment(ctx, dpPayment); err != nil {
+			return err
+		}
+	}
 
 	loanPrincipal := agreement.FinalPrice - req.DownPayment
 	totalInterest := loanPrincipal * (req.AnnualInterestRate / 100) * (float64(req.Tenor) / 12)
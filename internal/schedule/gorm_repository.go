@@ -3,7 +3,9 @@ package schedule
 import (
 	"context"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"mobigo-backend/internal/domain"
+	"time"
 )
 
 type gormRepository struct {
@@ -17,3 +19,36 @@ func NewGORMRepository(db *gorm.DB) Repository {
 func (r *gormRepository) CreateSchedule(ctx context.Context, schedule *domain.Schedule) error {
 	return r.db.WithContext(ctx).Create(schedule).Error
 }
+
+// GetSchedulesByStaff preloads the booking's customer and vehicle so calendar
+// views and the iCalendar feed can show who is coming and for which car.
+func (r *gormRepository) GetSchedulesByStaff(ctx context.Context, staffID int64, from, to time.Time) ([]*domain.Schedule, error) {
+	var schedules []*domain.Schedule
+	err := r.db.WithContext(ctx).
+		Preload("Booking.User").
+		Preload("Booking.Vehicle").
+		Where("user_id = ? AND appointment_datetime >= ? AND appointment_datetime < ?", staffID, from, to).
+		Order("appointment_datetime asc").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// SaveCalendarFeedToken upserts on user_id, so generating a new token revokes the old feed URL.
+func (r *gormRepository) SaveCalendarFeedToken(ctx context.Context, token *domain.CalendarFeedToken) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"token_hash", "updated_at"}),
+	}).Create(token).Error
+}
+
+func (r *gormRepository) GetCalendarFeedTokenByHash(ctx context.Context, tokenHash string) (*domain.CalendarFeedToken, error) {
+	var token domain.CalendarFeedToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
@@ -3,8 +3,11 @@ package schedule
 import (
 	"encoding/json"
 	"github.com/gorilla/mux"
+	"mobigo-backend/internal/domain"
 	"mobigo-backend/pkg/middleware"
+	"mobigo-backend/pkg/timeparam"
 	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -16,10 +19,20 @@ func NewHandler(s Service) *Handler {
 	return &Handler{service: s}
 }
 
-func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler) {
+func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler, authz *middleware.Authorizer) {
 	r := router.PathPrefix("/api/schedules").Subrouter()
 	r.Use(authMiddleware)
+	// Schedules are the staff calendar; customers see their appointments through their bookings.
+	r.Use(authz.Require(domain.RoleStaff, domain.RoleAdmin))
 	r.HandleFunc("", h.createScheduleHandler).Methods("POST")
+	r.HandleFunc("", h.listSchedulesHandler).Methods("GET")
+	r.HandleFunc("/day", h.listDaySchedulesHandler).Methods("GET")
+	r.HandleFunc("/week", h.listWeekSchedulesHandler).Methods("GET")
+	r.HandleFunc("/calendar-token", h.generateCalendarTokenHandler).Methods("POST")
+
+	// The iCalendar feed is fetched by calendar apps that cannot send a JWT,
+	// so it is authorized by the token embedded in the URL instead.
+	router.HandleFunc("/api/calendar/{token:[0-9a-f]+}.ics", h.calendarFeedHandler).Methods("GET")
 }
 
 type createScheduleRequest struct {
@@ -29,6 +42,11 @@ type createScheduleRequest struct {
 	Notes       string `json:"notes"`
 }
 
+type calendarTokenResponse struct {
+	Token   string `json:"token"`
+	FeedURL string `json:"feed_url"`
+}
+
 func (h *Handler) createScheduleHandler(w http.ResponseWriter, r *http.Request) {
 	// Get the logged-in staff member's ID from the context.
 	staffUserID, ok := r.Context().Value(middleware.UserIDKey).(int64)
@@ -60,3 +78,152 @@ func (h *Handler) createScheduleHandler(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(schedule)
 }
+
+// listSchedulesHandler returns a staff member's appointments in a date range.
+// Query params: from, to (YYYY-MM-DD or RFC3339; "to" is exclusive) and an optional staff_id.
+func (h *Handler) listSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	staffID, ok := staffIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+	from, err := timeparam.Parse(r.URL.Query().Get("from"))
+	if err != nil {
+		http.Error(w, "Invalid or missing 'from' parameter, use YYYY-MM-DD or RFC3339", http.StatusBadRequest)
+		return
+	}
+	to, err := timeparam.Parse(r.URL.Query().Get("to"))
+	if err != nil {
+		http.Error(w, "Invalid or missing 'to' parameter, use YYYY-MM-DD or RFC3339", http.StatusBadRequest)
+		return
+	}
+
+	schedules, err := h.service.ListStaffSchedules(r.Context(), staffID, from, to)
+	if err != nil {
+		if err.Error() == "end of range must be after its start" {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to retrieve schedules", http.StatusInternalServerError)
+		return
+	}
+	writeSchedules(w, schedules)
+}
+
+// listDaySchedulesHandler returns the appointments for a single day (?date=YYYY-MM-DD, defaults to today).
+func (h *Handler) listDaySchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	staffID, ok := staffIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+	day, err := parseDateParam(r.URL.Query().Get("date"))
+	if err != nil {
+		http.Error(w, "Invalid 'date' parameter, use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	schedules, err := h.service.ListStaffSchedulesForDay(r.Context(), staffID, day)
+	if err != nil {
+		http.Error(w, "Failed to retrieve schedules", http.StatusInternalServerError)
+		return
+	}
+	writeSchedules(w, schedules)
+}
+
+// listWeekSchedulesHandler returns the appointments for the week containing ?date= (defaults to this week).
+func (h *Handler) listWeekSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	staffID, ok := staffIDFromRequest(w, r)
+	if !ok {
+		return
+	}
+	day, err := parseDateParam(r.URL.Query().Get("date"))
+	if err != nil {
+		http.Error(w, "Invalid 'date' parameter, use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	schedules, err := h.service.ListStaffSchedulesForWeek(r.Context(), staffID, day)
+	if err != nil {
+		http.Error(w, "Failed to retrieve schedules", http.StatusInternalServerError)
+		return
+	}
+	writeSchedules(w, schedules)
+}
+
+// generateCalendarTokenHandler issues a new iCalendar feed URL for the logged-in staff member.
+// Generating a new token invalidates the previous feed URL.
+func (h *Handler) generateCalendarTokenHandler(w http.ResponseWriter, r *http.Request) {
+	staffID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+
+	token, err := h.service.GenerateCalendarFeedToken(r.Context(), staffID)
+	if err != nil {
+		http.Error(w, "Failed to generate calendar token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(calendarTokenResponse{
+		Token:   token,
+		FeedURL: "/api/calendar/" + token + ".ics",
+	})
+}
+
+func (h *Handler) calendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	token := mux.Vars(r)["token"]
+
+	feed, err := h.service.GetCalendarFeed(r.Context(), token)
+	if err != nil {
+		if err.Error() == "calendar feed not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to build calendar feed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="mobigo-appointments.ics"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(feed)
+}
+
+// staffIDFromRequest reads the optional staff_id query param, falling back to the logged-in user.
+// Only admins may look at another staff member's calendar.
+func staffIDFromRequest(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return 0, false
+	}
+	raw := r.URL.Query().Get("staff_id")
+	if raw == "" {
+		return userID, true
+	}
+	staffID, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid staff_id", http.StatusBadRequest)
+		return 0, false
+	}
+	if staffID != userID && !middleware.HasRole(r.Context(), domain.RoleAdmin) {
+		http.Error(w, "Forbidden: only admins may view another staff member's schedule", http.StatusForbidden)
+		return 0, false
+	}
+	return staffID, true
+}
+
+func parseDateParam(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Now(), nil
+	}
+	return time.ParseInLocation(timeparam.DateLayout, raw, time.Local)
+}
+
+func writeSchedules(w http.ResponseWriter, schedules []*domain.Schedule) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(schedules)
+}
@@ -0,0 +1,102 @@
+package schedule
+
+import (
+	"bytes"
+	"fmt"
+	"mobigo-backend/internal/domain"
+	"strings"
+	"time"
+)
+
+// AppointmentDuration is how long a showroom appointment blocks in the calendar
+// and at its branch. Schedules only store a start time, so both assume a fixed slot.
+const AppointmentDuration = time.Hour
+
+const icalTimeFormat = "20060102T150405Z"
+
+// renderICalendar builds an RFC 5545 VCALENDAR document from a list of schedules.
+// The schedules are expected to have Booking.User and Booking.Vehicle preloaded.
+func renderICalendar(schedules []*domain.Schedule, generatedAt time.Time) []byte {
+	var buf bytes.Buffer
+	writeLine(&buf, "BEGIN:VCALENDAR")
+	writeLine(&buf, "VERSION:2.0")
+	writeLine(&buf, "PRODID:-//MobiGo//Staff Appointments//EN")
+	writeLine(&buf, "CALSCALE:GREGORIAN")
+	writeLine(&buf, "METHOD:PUBLISH")
+	writeLine(&buf, "X-WR-CALNAME:MobiGo Appointments")
+
+	stamp := generatedAt.UTC().Format(icalTimeFormat)
+	for _, sch := range schedules {
+		customer, vehicle := describeBooking(sch.Booking)
+		start := sch.AppointmentDatetime.UTC()
+
+		description := fmt.Sprintf("Customer: %s\nVehicle: %s", customer, vehicle)
+		if sch.Notes != "" {
+			description += "\nNotes: " + sch.Notes
+		}
+
+		writeLine(&buf, "BEGIN:VEVENT")
+		writeLine(&buf, fmt.Sprintf("UID:schedule-%d@mobigo", sch.ID))
+		writeLine(&buf, "DTSTAMP:"+stamp)
+		writeLine(&buf, "DTSTART:"+start.Format(icalTimeFormat))
+		writeLine(&buf, "DTEND:"+start.Add(AppointmentDuration).Format(icalTimeFormat))
+		writeLine(&buf, "SUMMARY:"+escapeText(fmt.Sprintf("%s - %s", customer, vehicle)))
+		writeLine(&buf, "DESCRIPTION:"+escapeText(description))
+		writeLine(&buf, "STATUS:"+eventStatus(sch.Status))
+		writeLine(&buf, "LAST-MODIFIED:"+sch.UpdatedAt.UTC().Format(icalTimeFormat))
+		writeLine(&buf, "END:VEVENT")
+	}
+
+	writeLine(&buf, "END:VCALENDAR")
+	return buf.Bytes()
+}
+
+func describeBooking(booking *domain.Booking) (customer, vehicle string) {
+	customer, vehicle = "Unknown customer", "Unknown vehicle"
+	if booking == nil {
+		return
+	}
+	if booking.User != nil {
+		customer = booking.User.FullName
+	}
+	if booking.Vehicle != nil {
+		vehicle = fmt.Sprintf("%d %s %s", booking.Vehicle.Year, booking.Vehicle.Make, booking.Vehicle.Model)
+	}
+	return
+}
+
+func eventStatus(status domain.ScheduleStatus) string {
+	if status == domain.ScheduleStatusCancelled {
+		return "CANCELLED"
+	}
+	return "CONFIRMED"
+}
+
+// escapeText escapes the characters RFC 5545 reserves in TEXT values.
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// writeLine writes a content line, folding it at 75 octets as RFC 5545 requires.
+func writeLine(buf *bytes.Buffer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		// Never split a multi-byte UTF-8 sequence across folded lines.
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // continuation lines start with a space
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
@@ -3,8 +3,15 @@ package schedule
 import (
 	"context"
 	"mobigo-backend/internal/domain"
+	"time"
 )
 
 type Repository interface {
 	CreateSchedule(ctx context.Context, schedule *domain.Schedule) error
+	// GetSchedulesByStaff returns a staff member's appointments in [from, to), oldest first.
+	GetSchedulesByStaff(ctx context.Context, staffID int64, from, to time.Time) ([]*domain.Schedule, error)
+	// SaveCalendarFeedToken creates or replaces the feed token for a staff member.
+	SaveCalendarFeedToken(ctx context.Context, token *domain.CalendarFeedToken) error
+	// GetCalendarFeedTokenByHash finds a feed token by the hash of its raw value.
+	GetCalendarFeedTokenByHash(ctx context.Context, tokenHash string) (*domain.CalendarFeedToken, error)
 }
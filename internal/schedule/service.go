@@ -2,12 +2,31 @@ package schedule
 
 import (
 	"context"
+	"errors"
 	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/securetoken"
 	"time"
 )
 
+// How far back and ahead the iCalendar feed looks. Calendar apps re-poll the
+// feed, so there is no need to publish a staff member's entire history.
+const (
+	feedLookBehind = 30 * 24 * time.Hour
+	feedLookAhead  = 180 * 24 * time.Hour
+)
+
 type Service interface {
 	CreateSchedule(ctx context.Context, bookingID, staffUserID int64, apptTime time.Time, notes string) (*domain.Schedule, error)
+	// ListStaffSchedules returns the appointments of a staff member between from (inclusive) and to (exclusive).
+	ListStaffSchedules(ctx context.Context, staffID int64, from, to time.Time) ([]*domain.Schedule, error)
+	// ListStaffSchedulesForDay returns the appointments on the calendar day containing day.
+	ListStaffSchedulesForDay(ctx context.Context, staffID int64, day time.Time) ([]*domain.Schedule, error)
+	// ListStaffSchedulesForWeek returns the appointments in the Monday-to-Sunday week containing day.
+	ListStaffSchedulesForWeek(ctx context.Context, staffID int64, day time.Time) ([]*domain.Schedule, error)
+	// GenerateCalendarFeedToken issues a new feed token for a staff member, revoking any previous one.
+	GenerateCalendarFeedToken(ctx context.Context, staffID int64) (string, error)
+	// GetCalendarFeed renders the iCalendar feed that belongs to the given token.
+	GetCalendarFeed(ctx context.Context, token string) ([]byte, error)
 }
 type service struct {
 	repo Repository
@@ -28,3 +47,61 @@ func (s *service) CreateSchedule(ctx context.Context, bookingID, staffUserID int
 	err := s.repo.CreateSchedule(ctx, newSchedule)
 	return newSchedule, err
 }
+
+func (s *service) ListStaffSchedules(ctx context.Context, staffID int64, from, to time.Time) ([]*domain.Schedule, error) {
+	if !to.After(from) {
+		return nil, errors.New("end of range must be after its start")
+	}
+	return s.repo.GetSchedulesByStaff(ctx, staffID, from, to)
+}
+
+func (s *service) ListStaffSchedulesForDay(ctx context.Context, staffID int64, day time.Time) ([]*domain.Schedule, error) {
+	start := startOfDay(day)
+	return s.repo.GetSchedulesByStaff(ctx, staffID, start, start.AddDate(0, 0, 1))
+}
+
+func (s *service) ListStaffSchedulesForWeek(ctx context.Context, staffID int64, day time.Time) ([]*domain.Schedule, error) {
+	start := startOfDay(day)
+	// time.Weekday starts on Sunday; shift so the week starts on Monday.
+	offset := (int(start.Weekday()) + 6) % 7
+	start = start.AddDate(0, 0, -offset)
+	return s.repo.GetSchedulesByStaff(ctx, staffID, start, start.AddDate(0, 0, 7))
+}
+
+func (s *service) GenerateCalendarFeedToken(ctx context.Context, staffID int64) (string, error) {
+	token, err := securetoken.Generate()
+	if err != nil {
+		return "", err
+	}
+
+	feedToken := &domain.CalendarFeedToken{
+		UserID:    staffID,
+		TokenHash: securetoken.Hash(token),
+	}
+	if err := s.repo.SaveCalendarFeedToken(ctx, feedToken); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *service) GetCalendarFeed(ctx context.Context, token string) ([]byte, error) {
+	feedToken, err := s.repo.GetCalendarFeedTokenByHash(ctx, securetoken.Hash(token))
+	if err != nil {
+		return nil, err
+	}
+	if feedToken == nil {
+		return nil, errors.New("calendar feed not found")
+	}
+
+	now := time.Now()
+	schedules, err := s.repo.GetSchedulesByStaff(ctx, feedToken.UserID, now.Add(-feedLookBehind), now.Add(feedLookAhead))
+	if err != nil {
+		return nil, err
+	}
+	return renderICalendar(schedules, now), nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
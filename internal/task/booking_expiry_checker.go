@@ -0,0 +1,89 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mobigo-backend/internal/booking"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/internal/notification"
+	"mobigo-backend/internal/waitlist"
+	"time"
+)
+
+// BookingExpiryChecker expires pending bookings whose proposed time has passed
+// without a staff member confirming them.
+type BookingExpiryChecker struct {
+	bookingRepo         booking.Repository
+	waitlistService     waitlist.Service
+	notificationService notification.Service
+	gracePeriod         time.Duration
+}
+
+// NewBookingExpiryChecker creates a new instance of the BookingExpiryChecker.
+// A pending booking expires once its proposed time is more than gracePeriod in the past.
+func NewBookingExpiryChecker(bookingRepo booking.Repository, waitlistService waitlist.Service, notificationService notification.Service, gracePeriod time.Duration) *BookingExpiryChecker {
+	return &BookingExpiryChecker{
+		bookingRepo:         bookingRepo,
+		waitlistService:     waitlistService,
+		notificationService: notificationService,
+		gracePeriod:         gracePeriod,
+	}
+}
+
+// Run is the function that will be executed by the cron job.
+func (bc *BookingExpiryChecker) Run() {
+	log.Println("CRON JOB: Starting check for stale pending bookings...")
+
+	ctx := context.Background()
+	cutoff := time.Now().Add(-bc.gracePeriod)
+
+	// 1. Get all pending bookings whose proposed time is past the cutoff.
+	staleBookings, err := bc.bookingRepo.FindStalePendingBookings(ctx, cutoff)
+	if err != nil {
+		log.Printf("CRON ERROR: Could not fetch stale pending bookings: %v", err)
+		return
+	}
+
+	if len(staleBookings) == 0 {
+		log.Println("CRON JOB: No stale pending bookings found. Finished.")
+		return
+	}
+
+	log.Printf("CRON JOB: Found %d stale pending booking(s). Expiring...", len(staleBookings))
+
+	for _, b := range staleBookings {
+		// 2. Expire the booking, unless staff confirmed it since we read it.
+		expired, err := bc.bookingRepo.ExpireBooking(ctx, b.ID)
+		if err != nil {
+			log.Printf("CRON ERROR: Failed to expire booking ID %d: %v", b.ID, err)
+			continue
+		}
+		if !expired {
+			continue
+		}
+
+		// 3. Offer the vehicle to whoever is next on its waitlist. A pending booking never
+		// took the vehicle, so it is not newly available and favourites are not alerted.
+		if err := bc.waitlistService.VehicleReleased(ctx, b.VehicleID); err != nil {
+			log.Printf("CRON ERROR: Failed to release waitlist hold for vehicle ID %d: %v", b.VehicleID, err)
+		}
+
+		// 4. Let the customer know.
+		if err := bc.notificationService.Notify(ctx, b.UserID, domain.NotificationTypeBookingExpired,
+			"Your booking has expired", expiryMessage(b)); err != nil {
+			log.Printf("CRON ERROR: Failed to notify user ID %d about booking ID %d: %v", b.UserID, b.ID, err)
+		}
+		log.Printf("CRON JOB: Expired booking ID %d.", b.ID)
+	}
+
+	log.Println("CRON JOB: Finished expiring stale bookings.")
+}
+
+func expiryMessage(b *domain.Booking) string {
+	vehicle := "the vehicle"
+	if b.Vehicle != nil {
+		vehicle = fmt.Sprintf("the %d %s %s", b.Vehicle.Year, b.Vehicle.Make, b.Vehicle.Model)
+	}
+	return fmt.Sprintf("Your viewing request for %s was not confirmed before the proposed time, so it has expired. You are welcome to book a new time.", vehicle)
+}
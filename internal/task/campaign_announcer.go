@@ -0,0 +1,32 @@
+package task
+
+import (
+	"context"
+	"log"
+	"mobigo-backend/internal/favourite"
+)
+
+// CampaignAnnouncer tells customers when a discount campaign that started or was
+// edited makes a vehicle they saved cheaper.
+type CampaignAnnouncer struct {
+	favouriteService favourite.Service
+}
+
+// NewCampaignAnnouncer creates a new instance of the CampaignAnnouncer.
+func NewCampaignAnnouncer(s favourite.Service) *CampaignAnnouncer {
+	return &CampaignAnnouncer{
+		favouriteService: s,
+	}
+}
+
+// Run is the function that will be executed by the cron job.
+func (ca *CampaignAnnouncer) Run() {
+	log.Println("CRON JOB: Announcing discount campaign price drops...")
+
+	if err := ca.favouriteService.AnnounceCampaigns(context.Background()); err != nil {
+		log.Printf("CRON ERROR: Could not announce campaign price drops: %v", err)
+		return
+	}
+
+	log.Println("CRON JOB: Finished announcing campaign price drops.")
+}
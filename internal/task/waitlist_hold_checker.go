@@ -0,0 +1,31 @@
+package task
+
+import (
+	"context"
+	"log"
+	"mobigo-backend/internal/waitlist"
+)
+
+// WaitlistHoldChecker expires waitlist holds that customers did not use in time.
+type WaitlistHoldChecker struct {
+	waitlistService waitlist.Service
+}
+
+// NewWaitlistHoldChecker creates a new instance of the WaitlistHoldChecker.
+func NewWaitlistHoldChecker(s waitlist.Service) *WaitlistHoldChecker {
+	return &WaitlistHoldChecker{
+		waitlistService: s,
+	}
+}
+
+// Run is the function that will be executed by the cron job.
+func (wc *WaitlistHoldChecker) Run() {
+	log.Println("CRON JOB: Checking for expired waitlist holds...")
+
+	if err := wc.waitlistService.ExpireHolds(context.Background()); err != nil {
+		log.Printf("CRON ERROR: Could not expire waitlist holds: %v", err)
+		return
+	}
+
+	log.Println("CRON JOB: Finished checking waitlist holds.")
+}
@@ -0,0 +1,104 @@
+package tradein
+
+import (
+	"context"
+	"mobigo-backend/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormRepository struct {
+	db *gorm.DB
+}
+
+func NewGORMRepository(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) Create(ctx context.Context, tradeIn *domain.TradeIn) error {
+	return r.db.WithContext(ctx).Create(tradeIn).Error
+}
+
+func (r *gormRepository) GetByID(ctx context.Context, id int64) (*domain.TradeIn, error) {
+	var tradeIn domain.TradeIn
+	if err := r.db.WithContext(ctx).Preload("Photos").First(&tradeIn, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tradeIn, nil
+}
+
+func (r *gormRepository) ListByUser(ctx context.Context, userID int64) ([]*domain.TradeIn, error) {
+	var tradeIns []*domain.TradeIn
+	err := r.db.WithContext(ctx).Preload("Photos").
+		Where("user_id = ?", userID).
+		Order("created_at desc").
+		Find(&tradeIns).Error
+	return tradeIns, err
+}
+
+func (r *gormRepository) Find(ctx context.Context, filter Filter) ([]*domain.TradeIn, error) {
+	query := r.db.WithContext(ctx).Preload("Photos").Preload("User")
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	var tradeIns []*domain.TradeIn
+	err := query.Order("created_at asc").Find(&tradeIns).Error
+	return tradeIns, err
+}
+
+func (r *gormRepository) SaveAppraisal(ctx context.Context, tradeIn *domain.TradeIn, from ...domain.TradeInStatus) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.TradeIn{}).
+		Where("id = ? AND status IN ?", tradeIn.ID, from).
+		Updates(map[string]interface{}{
+			"status":           tradeIn.Status,
+			"appraised_value":  tradeIn.AppraisedValue,
+			"appraisal_notes":  tradeIn.AppraisalNotes,
+			"appraised_by_id":  tradeIn.AppraisedByID,
+			"appraised_at":     tradeIn.AppraisedAt,
+			"offer_expires_at": tradeIn.OfferExpiresAt,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *gormRepository) UpdateStatus(ctx context.Context, tradeInID int64, to domain.TradeInStatus, from ...domain.TradeInStatus) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.TradeIn{}).
+		Where("id = ? AND status IN ?", tradeInID, from).
+		Update("status", to)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *gormRepository) RespondToOffer(ctx context.Context, tradeInID int64, appraisedAt time.Time, to domain.TradeInStatus) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.TradeIn{}).
+		Where("id = ? AND status = ? AND appraised_at = ?", tradeInID, domain.TradeInStatusAppraised, appraisedAt).
+		Update("status", to)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *gormRepository) AddPhoto(ctx context.Context, photo *domain.TradeInPhoto, maxPhotos int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locking the trade-in serializes uploads with each other and with appraisals.
+		var tradeIn domain.TradeIn
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tradeIn, photo.TradeInID).Error; err != nil {
+			return err
+		}
+		if tradeIn.Status != domain.TradeInStatusSubmitted {
+			return ErrPhotosClosed
+		}
+		var count int64
+		if err := tx.Model(&domain.TradeInPhoto{}).Where("trade_in_id = ?", photo.TradeInID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= int64(maxPhotos) {
+			return ErrTooManyPhotos
+		}
+		return tx.Create(photo).Error
+	})
+}
@@ -0,0 +1,367 @@
+package tradein
+
+import (
+	"encoding/json"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(s Service) *Handler {
+	return &Handler{service: s}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler, authz *middleware.Authorizer) {
+	customerOnly := authz.Require(domain.RoleCustomer)
+	staffOnly := authz.Require(domain.RoleStaff, domain.RoleAdmin)
+
+	r := router.PathPrefix("/api/trade-ins").Subrouter()
+	r.Use(authMiddleware)
+
+	// Customer routes: always scoped to the user in the JWT.
+	r.Handle("", customerOnly(http.HandlerFunc(h.submitTradeInHandler))).Methods("POST")
+	r.Handle("/me", customerOnly(http.HandlerFunc(h.listMyTradeInsHandler))).Methods("GET")
+	r.Handle("/me/{id:[0-9]+}", customerOnly(http.HandlerFunc(h.getMyTradeInHandler))).Methods("GET")
+	r.Handle("/me/{id:[0-9]+}", customerOnly(http.HandlerFunc(h.withdrawTradeInHandler))).Methods("DELETE")
+	r.Handle("/me/{id:[0-9]+}/photos", customerOnly(http.HandlerFunc(h.uploadPhotoHandler))).Methods("POST")
+	r.Handle("/me/{id:[0-9]+}/photos/{photoID:[0-9]+}", customerOnly(http.HandlerFunc(h.downloadMyPhotoHandler))).Methods("GET")
+	r.Handle("/me/{id:[0-9]+}/decision", customerOnly(http.HandlerFunc(h.respondHandler))).Methods("PUT")
+
+	// Staff routes: the appraisal queue.
+	r.Handle("", staffOnly(http.HandlerFunc(h.listTradeInsHandler))).Methods("GET")
+	r.Handle("/{id:[0-9]+}", staffOnly(http.HandlerFunc(h.getTradeInHandler))).Methods("GET")
+	r.Handle("/{id:[0-9]+}/photos/{photoID:[0-9]+}", staffOnly(http.HandlerFunc(h.downloadPhotoHandler))).Methods("GET")
+	r.Handle("/{id:[0-9]+}/appraisal", staffOnly(http.HandlerFunc(h.appraiseHandler))).Methods("PUT")
+}
+
+type submitTradeInRequest struct {
+	Make        string `json:"make"`
+	Model       string `json:"model"`
+	Year        int    `json:"year"`
+	Mileage     int    `json:"mileage"`
+	PlateNumber string `json:"plate_number"`
+	VIN         string `json:"vin"`
+	Description string `json:"description"`
+}
+
+type respondRequest struct {
+	Accept bool `json:"accept"`
+}
+
+type appraiseRequest struct {
+	Value float64 `json:"value"`
+	Notes string  `json:"notes"`
+}
+
+func (h *Handler) submitTradeInHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	var req submitTradeInRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tradeIn, err := h.service.Submit(r.Context(), userID, Details{
+		Make:        req.Make,
+		Model:       req.Model,
+		Year:        req.Year,
+		Mileage:     req.Mileage,
+		PlateNumber: req.PlateNumber,
+		VIN:         req.VIN,
+		Description: req.Description,
+	})
+	if err != nil {
+		switch err.Error() {
+		case "make and model are required", "year is out of range", "mileage cannot be negative":
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to submit trade-in", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tradeIn)
+}
+
+func (h *Handler) listMyTradeInsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+
+	tradeIns, err := h.service.ListForUser(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve trade-ins", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tradeIns)
+}
+
+func (h *Handler) getMyTradeInHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	tradeInID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	tradeIn, err := h.service.GetForUser(r.Context(), userID, tradeInID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve trade-in", http.StatusInternalServerError)
+		return
+	}
+	if tradeIn == nil {
+		http.Error(w, "Trade-in not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tradeIn)
+}
+
+func (h *Handler) withdrawTradeInHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	tradeInID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	if err := h.service.Withdraw(r.Context(), userID, tradeInID); err != nil {
+		switch err.Error() {
+		case "trade-in not found":
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case "trade-in can no longer be withdrawn":
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to withdraw trade-in", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// uploadPhotoHandler takes a multipart form with a "file" field.
+func (h *Handler) uploadPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	tradeInID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxPhotoSize+1<<20) // Room for the form fields
+	if err := r.ParseMultipartForm(MaxPhotoSize); err != nil {
+		http.Error(w, "Could not parse multipart form", http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Invalid photo file", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	photo, err := h.service.AddPhoto(r.Context(), userID, tradeInID, header.Filename, file)
+	if err != nil {
+		switch err.Error() {
+		case "trade-in not found":
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case "photos can only be added before the appraisal":
+			http.Error(w, err.Error(), http.StatusConflict)
+		case "photos must be JPEG or PNG files", "photo is larger than 10 MB", "too many photos for this trade-in":
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to save photo", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(photo)
+}
+
+func (h *Handler) downloadMyPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	tradeInID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	tradeIn, err := h.service.GetForUser(r.Context(), userID, tradeInID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve trade-in", http.StatusInternalServerError)
+		return
+	}
+	h.servePhoto(w, r, tradeIn)
+}
+
+func (h *Handler) respondHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	tradeInID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	var req respondRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tradeIn, err := h.service.Respond(r.Context(), userID, tradeInID, req.Accept)
+	if err != nil {
+		switch err.Error() {
+		case "trade-in not found":
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case "there is no offer to respond to", "the offer has expired", "the offer has changed; please review it again":
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to record decision", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tradeIn)
+}
+
+// listTradeInsHandler lists trade-ins for appraisal. Query params: status, user_id.
+func (h *Handler) listTradeInsHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := Filter{Status: domain.TradeInStatus(q.Get("status"))}
+	if userID := q.Get("user_id"); userID != "" {
+		id, err := strconv.ParseInt(userID, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid user_id", http.StatusBadRequest)
+			return
+		}
+		filter.UserID = id
+	}
+
+	tradeIns, err := h.service.List(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "Failed to retrieve trade-ins", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tradeIns)
+}
+
+func (h *Handler) getTradeInHandler(w http.ResponseWriter, r *http.Request) {
+	tradeInID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	tradeIn, err := h.service.GetTradeIn(r.Context(), tradeInID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve trade-in", http.StatusInternalServerError)
+		return
+	}
+	if tradeIn == nil {
+		http.Error(w, "Trade-in not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tradeIn)
+}
+
+func (h *Handler) downloadPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	tradeInID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	tradeIn, err := h.service.GetTradeIn(r.Context(), tradeInID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve trade-in", http.StatusInternalServerError)
+		return
+	}
+	h.servePhoto(w, r, tradeIn)
+}
+
+func (h *Handler) appraiseHandler(w http.ResponseWriter, r *http.Request) {
+	staffID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	tradeInID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	var req appraiseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tradeIn, err := h.service.Appraise(r.Context(), staffID, tradeInID, req.Value, req.Notes)
+	if err != nil {
+		switch err.Error() {
+		case "trade-in not found":
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case "trade-in can no longer be appraised":
+			http.Error(w, err.Error(), http.StatusConflict)
+		case "appraised value must be positive":
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to record appraisal", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tradeIn)
+}
+
+// servePhoto streams one of the trade-in's photos. Files are never served from a public path.
+func (h *Handler) servePhoto(w http.ResponseWriter, r *http.Request, tradeIn *domain.TradeIn) {
+	if tradeIn == nil {
+		http.Error(w, "Trade-in not found", http.StatusNotFound)
+		return
+	}
+	photoID, _ := strconv.ParseInt(mux.Vars(r)["photoID"], 10, 64)
+
+	photo, file, err := h.service.OpenPhoto(tradeIn, photoID)
+	if err != nil {
+		http.Error(w, "Photo file is unavailable", http.StatusInternalServerError)
+		return
+	}
+	if photo == nil {
+		http.Error(w, "Photo not found", http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, "Photo file is unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", photo.ContentType)
+	w.Header().Set("Content-Disposition", "inline; filename="+strconv.Quote(photo.FileName))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, "", info.ModTime(), file)
+}
@@ -0,0 +1,47 @@
+package tradein
+
+import (
+	"context"
+	"errors"
+	"mobigo-backend/internal/domain"
+	"time"
+)
+
+// Errors returned by AddPhoto when the trade-in changed between the service's read
+// and the locked write.
+var (
+	ErrPhotosClosed  = errors.New("photos can only be added before the appraisal")
+	ErrTooManyPhotos = errors.New("too many photos for this trade-in")
+)
+
+// Filter narrows down the staff appraisal list. Zero values are ignored.
+type Filter struct {
+	Status domain.TradeInStatus
+	UserID int64
+}
+
+// Repository defines the interface for trade-in storage. Trade-ins are always
+// loaded with their photos.
+type Repository interface {
+	Create(ctx context.Context, tradeIn *domain.TradeIn) error
+	GetByID(ctx context.Context, id int64) (*domain.TradeIn, error)
+	// ListByUser returns a customer's trade-ins, newest first.
+	ListByUser(ctx context.Context, userID int64) ([]*domain.TradeIn, error)
+	// Find returns trade-ins matching the filter with their owners loaded, oldest first
+	// so the appraisal queue is worked through in order.
+	Find(ctx context.Context, filter Filter) ([]*domain.TradeIn, error)
+	// SaveAppraisal saves the trade-in's status and appraisal only if its stored status
+	// is still one of from. It reports false if another request changed it first.
+	SaveAppraisal(ctx context.Context, tradeIn *domain.TradeIn, from ...domain.TradeInStatus) (bool, error)
+	// UpdateStatus sets only the status, and only if the stored status is still one of
+	// from. It reports false if another request changed it first.
+	UpdateStatus(ctx context.Context, tradeInID int64, to domain.TradeInStatus, from ...domain.TradeInStatus) (bool, error)
+	// RespondToOffer sets the status of an appraised trade-in only if its offer is still
+	// the one appraised at appraisedAt. It reports false if the trade-in was re-appraised
+	// or changed in another way first.
+	RespondToOffer(ctx context.Context, tradeInID int64, appraisedAt time.Time, to domain.TradeInStatus) (bool, error)
+	// AddPhoto saves a photo while the trade-in is locked, so concurrent uploads cannot
+	// exceed maxPhotos or land after the appraisal. It returns ErrPhotosClosed or
+	// ErrTooManyPhotos if they would.
+	AddPhoto(ctx context.Context, photo *domain.TradeInPhoto, maxPhotos int) error
+}
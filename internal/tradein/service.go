@@ -0,0 +1,291 @@
+package tradein
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/privatefile"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MaxPhotoSize is the largest photo that can be uploaded.
+const MaxPhotoSize = 10 << 20 // 10 MB
+
+// MaxPhotos is the number of photos a trade-in can have.
+const MaxPhotos = 12
+
+// allowedContentTypes are the photo formats accepted, detected from the file content.
+var allowedContentTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// Notifier delivers in-app notifications to customers.
+type Notifier interface {
+	Notify(ctx context.Context, userID int64, notificationType domain.NotificationType, title, message string) error
+}
+
+// Details are the vehicle details a customer submits.
+type Details struct {
+	Make        string
+	Model       string
+	Year        int
+	Mileage     int
+	PlateNumber string
+	VIN         string
+	Description string
+}
+
+// Service manages trade-ins from submission through appraisal to being credited on an agreement.
+type Service interface {
+	Submit(ctx context.Context, userID int64, details Details) (*domain.TradeIn, error)
+	// AddPhoto stores a photo for a trade-in that has not been appraised yet.
+	AddPhoto(ctx context.Context, userID, tradeInID int64, fileName string, content io.Reader) (*domain.TradeInPhoto, error)
+	ListForUser(ctx context.Context, userID int64) ([]*domain.TradeIn, error)
+	// GetForUser returns one of the customer's own trade-ins, or nil if it is not theirs.
+	GetForUser(ctx context.Context, userID, tradeInID int64) (*domain.TradeIn, error)
+	// Respond accepts or declines an appraisal offer that has not expired.
+	Respond(ctx context.Context, userID, tradeInID int64, accept bool) (*domain.TradeIn, error)
+	// Withdraw cancels a trade-in that has not been applied to an agreement.
+	Withdraw(ctx context.Context, userID, tradeInID int64) error
+	List(ctx context.Context, filter Filter) ([]*domain.TradeIn, error)
+	GetTradeIn(ctx context.Context, tradeInID int64) (*domain.TradeIn, error)
+	// Appraise records the value staff offer for a trade-in and notifies the customer.
+	// A trade-in can be re-appraised until the customer has responded.
+	Appraise(ctx context.Context, staffID, tradeInID int64, value float64, notes string) (*domain.TradeIn, error)
+	// OpenPhoto opens a photo of the trade-in for reading, or returns nil if the photo is not part of it.
+	OpenPhoto(tradeIn *domain.TradeIn, photoID int64) (*domain.TradeInPhoto, *os.File, error)
+	// AcceptedCredit returns the value of an accepted trade-in owned by the user. The
+	// agreement repository moves it to applied when the agreement is created.
+	AcceptedCredit(ctx context.Context, tradeInID, userID int64) (float64, error)
+}
+
+type service struct {
+	repo     Repository
+	notifier Notifier
+	files    privatefile.Store
+	offerTTL time.Duration
+}
+
+// NewService creates a trade-in service that keeps photos in storageDir, which must
+// not be publicly served. Appraisal offers are valid for offerTTL.
+func NewService(repo Repository, notifier Notifier, storageDir string, offerTTL time.Duration) Service {
+	files := privatefile.Store{Dir: storageDir, Types: allowedContentTypes, MaxSize: MaxPhotoSize}
+	return &service{repo: repo, notifier: notifier, files: files, offerTTL: offerTTL}
+}
+
+func (s *service) Submit(ctx context.Context, userID int64, details Details) (*domain.TradeIn, error) {
+	details.Make = strings.TrimSpace(details.Make)
+	details.Model = strings.TrimSpace(details.Model)
+	if details.Make == "" || details.Model == "" {
+		return nil, errors.New("make and model are required")
+	}
+	if details.Year < 1980 || details.Year > time.Now().Year()+1 {
+		return nil, errors.New("year is out of range")
+	}
+	if details.Mileage < 0 {
+		return nil, errors.New("mileage cannot be negative")
+	}
+
+	tradeIn := &domain.TradeIn{
+		UserID:      userID,
+		Make:        details.Make,
+		Model:       details.Model,
+		Year:        details.Year,
+		Mileage:     details.Mileage,
+		PlateNumber: strings.ToUpper(strings.TrimSpace(details.PlateNumber)),
+		VIN:         strings.ToUpper(strings.TrimSpace(details.VIN)),
+		Description: details.Description,
+		Status:      domain.TradeInStatusSubmitted,
+	}
+	if err := s.repo.Create(ctx, tradeIn); err != nil {
+		return nil, err
+	}
+	return tradeIn, nil
+}
+
+func (s *service) AddPhoto(ctx context.Context, userID, tradeInID int64, fileName string, content io.Reader) (*domain.TradeInPhoto, error) {
+	tradeIn, err := s.GetForUser(ctx, userID, tradeInID)
+	if err != nil {
+		return nil, err
+	}
+	if tradeIn == nil {
+		return nil, errors.New("trade-in not found")
+	}
+	// Checked again under a lock when the photo is saved; this saves storing a file
+	// that would be rejected anyway.
+	if tradeIn.Status != domain.TradeInStatusSubmitted {
+		return nil, ErrPhotosClosed
+	}
+	if len(tradeIn.Photos) >= MaxPhotos {
+		return nil, ErrTooManyPhotos
+	}
+
+	file, err := s.files.Save(content)
+	if err != nil {
+		switch {
+		case errors.Is(err, privatefile.ErrUnsupportedType):
+			return nil, errors.New("photos must be JPEG or PNG files")
+		case errors.Is(err, privatefile.ErrTooLarge):
+			return nil, errors.New("photo is larger than 10 MB")
+		}
+		return nil, err
+	}
+
+	photo := &domain.TradeInPhoto{
+		TradeInID:   tradeIn.ID,
+		FileName:    filepath.Base(fileName),
+		StoragePath: file.Path,
+		ContentType: file.ContentType,
+	}
+	if err := s.repo.AddPhoto(ctx, photo, MaxPhotos); err != nil {
+		os.Remove(file.Path)
+		return nil, err
+	}
+	return photo, nil
+}
+
+func (s *service) ListForUser(ctx context.Context, userID int64) ([]*domain.TradeIn, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+func (s *service) GetForUser(ctx context.Context, userID, tradeInID int64) (*domain.TradeIn, error) {
+	tradeIn, err := s.repo.GetByID(ctx, tradeInID)
+	if err != nil {
+		return nil, err
+	}
+	if tradeIn == nil || tradeIn.UserID != userID {
+		return nil, nil
+	}
+	return tradeIn, nil
+}
+
+func (s *service) Respond(ctx context.Context, userID, tradeInID int64, accept bool) (*domain.TradeIn, error) {
+	tradeIn, err := s.GetForUser(ctx, userID, tradeInID)
+	if err != nil {
+		return nil, err
+	}
+	if tradeIn == nil {
+		return nil, errors.New("trade-in not found")
+	}
+	if tradeIn.Status != domain.TradeInStatusAppraised {
+		return nil, errors.New("there is no offer to respond to")
+	}
+	if accept && tradeIn.OfferExpiresAt != nil && time.Now().After(*tradeIn.OfferExpiresAt) {
+		return nil, errors.New("the offer has expired")
+	}
+
+	status := domain.TradeInStatusDeclined
+	if accept {
+		status = domain.TradeInStatusAccepted
+	}
+	// The update only applies to the appraisal the customer saw, so a re-appraisal made
+	// in the meantime is not accepted or declined unseen.
+	ok, err := s.repo.RespondToOffer(ctx, tradeIn.ID, *tradeIn.AppraisedAt, status)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("the offer has changed; please review it again")
+	}
+	tradeIn.Status = status
+	return tradeIn, nil
+}
+
+func (s *service) Withdraw(ctx context.Context, userID, tradeInID int64) error {
+	tradeIn, err := s.GetForUser(ctx, userID, tradeInID)
+	if err != nil {
+		return err
+	}
+	if tradeIn == nil {
+		return errors.New("trade-in not found")
+	}
+	// The status check is part of the update, so a trade-in being credited on an
+	// agreement at the same moment is either applied or withdrawn, never both.
+	ok, err := s.repo.UpdateStatus(ctx, tradeIn.ID, domain.TradeInStatusWithdrawn,
+		domain.TradeInStatusSubmitted, domain.TradeInStatusAppraised, domain.TradeInStatusAccepted, domain.TradeInStatusDeclined)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("trade-in can no longer be withdrawn")
+	}
+	return nil
+}
+
+func (s *service) List(ctx context.Context, filter Filter) ([]*domain.TradeIn, error) {
+	return s.repo.Find(ctx, filter)
+}
+
+func (s *service) GetTradeIn(ctx context.Context, tradeInID int64) (*domain.TradeIn, error) {
+	return s.repo.GetByID(ctx, tradeInID)
+}
+
+func (s *service) Appraise(ctx context.Context, staffID, tradeInID int64, value float64, notes string) (*domain.TradeIn, error) {
+	tradeIn, err := s.repo.GetByID(ctx, tradeInID)
+	if err != nil {
+		return nil, err
+	}
+	if tradeIn == nil {
+		return nil, errors.New("trade-in not found")
+	}
+	if tradeIn.Status != domain.TradeInStatusSubmitted && tradeIn.Status != domain.TradeInStatusAppraised {
+		return nil, errors.New("trade-in can no longer be appraised")
+	}
+	if value <= 0 {
+		return nil, errors.New("appraised value must be positive")
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.offerTTL)
+	tradeIn.Status = domain.TradeInStatusAppraised
+	tradeIn.AppraisedValue = value
+	tradeIn.AppraisalNotes = notes
+	tradeIn.AppraisedByID = &staffID
+	tradeIn.AppraisedAt = &now
+	tradeIn.OfferExpiresAt = &expiresAt
+	ok, err := s.repo.SaveAppraisal(ctx, tradeIn, domain.TradeInStatusSubmitted, domain.TradeInStatusAppraised)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("trade-in can no longer be appraised")
+	}
+
+	title := fmt.Sprintf("Your %d %s %s has been appraised", tradeIn.Year, tradeIn.Make, tradeIn.Model)
+	message := fmt.Sprintf("We can offer %.2f as trade-in credit, valid until %s.", value, expiresAt.Format("2 Jan 2006"))
+	if err := s.notifier.Notify(ctx, tradeIn.UserID, domain.NotificationTypeTradeInAppraised, title, message); err != nil {
+		log.Printf("TRADE-IN ERROR: Failed to notify user %d: %v", tradeIn.UserID, err)
+	}
+	return tradeIn, nil
+}
+
+func (s *service) OpenPhoto(tradeIn *domain.TradeIn, photoID int64) (*domain.TradeInPhoto, *os.File, error) {
+	for _, photo := range tradeIn.Photos {
+		if photo.ID == photoID {
+			file, err := os.Open(photo.StoragePath)
+			return photo, file, err
+		}
+	}
+	return nil, nil, nil
+}
+
+func (s *service) AcceptedCredit(ctx context.Context, tradeInID, userID int64) (float64, error) {
+	tradeIn, err := s.GetForUser(ctx, userID, tradeInID)
+	if err != nil {
+		return 0, err
+	}
+	if tradeIn == nil {
+		return 0, errors.New("trade-in not found for this customer")
+	}
+	if tradeIn.Status != domain.TradeInStatusAccepted {
+		return 0, errors.New("trade-in offer has not been accepted")
+	}
+	return tradeIn.AppraisedValue, nil
+}
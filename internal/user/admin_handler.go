@@ -0,0 +1,382 @@
+package user
+
+import (
+	"encoding/json"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler holds the dependencies for the admin user management handlers.
+type AdminHandler struct {
+	service AdminService
+}
+
+// NewAdminHandler creates a new instance of the admin user handler.
+func NewAdminHandler(s AdminService) *AdminHandler {
+	return &AdminHandler{service: s}
+}
+
+// RegisterRoutes sets up the admin-only user management routes.
+func (h *AdminHandler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler, authz *middleware.Authorizer) {
+	r := router.PathPrefix("/api/admin/users").Subrouter()
+	r.Use(authMiddleware, authz.Require(domain.RoleAdmin))
+
+	r.HandleFunc("", h.listUsersHandler).Methods("GET")
+	r.HandleFunc("/{id:[0-9]+}", h.getUserHandler).Methods("GET")
+	r.HandleFunc("/{id:[0-9]+}", h.deleteUserHandler).Methods("DELETE")
+	r.HandleFunc("/{id:[0-9]+}/roles", h.assignRoleHandler).Methods("POST")
+	r.HandleFunc("/{id:[0-9]+}/roles/{role}", h.revokeRoleHandler).Methods("DELETE")
+	r.HandleFunc("/{id:[0-9]+}/deactivate", h.deactivateUserHandler).Methods("PUT")
+	r.HandleFunc("/{id:[0-9]+}/reactivate", h.reactivateUserHandler).Methods("PUT")
+	r.HandleFunc("/{id:[0-9]+}/unlock", h.unlockUserHandler).Methods("PUT")
+	r.HandleFunc("/{id:[0-9]+}/two-factor", h.setTwoFactorRequiredHandler).Methods("PUT")
+	r.HandleFunc("/{id:[0-9]+}/two-factor", h.resetTwoFactorHandler).Methods("DELETE")
+	r.HandleFunc("/{id:[0-9]+}/password", h.resetPasswordHandler).Methods("PUT")
+
+	invitations := router.PathPrefix("/api/admin/invitations").Subrouter()
+	invitations.Use(authMiddleware, authz.Require(domain.RoleAdmin))
+	invitations.HandleFunc("", h.createInvitationHandler).Methods("POST")
+	invitations.HandleFunc("", h.listInvitationsHandler).Methods("GET")
+	invitations.HandleFunc("/{id:[0-9]+}", h.revokeInvitationHandler).Methods("DELETE")
+}
+
+type listUsersResponse struct {
+	Data     []*domain.User `json:"data"`
+	Total    int64          `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+}
+
+type assignRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type resetPasswordRequest struct {
+	NewPassword string `json:"new_password"` // Optional; a temporary password is generated if empty
+}
+
+type resetPasswordResponse struct {
+	TemporaryPassword string `json:"temporary_password,omitempty"`
+}
+
+type setTwoFactorRequiredRequest struct {
+	Required bool `json:"required"`
+}
+
+type createInvitationRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"` // "staff" or "admin"
+}
+
+type createInvitationResponse struct {
+	*domain.StaffInvitation
+	Token string `json:"token"`
+}
+
+// listUsersHandler lists users. Query params: search, role, status (active|deactivated|deletion_requested), page, page_size.
+func (h *AdminHandler) listUsersHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := ListFilter{
+		Search: q.Get("search"),
+		Role:   q.Get("role"),
+		Status: q.Get("status"),
+	}
+	filter.Page, _ = strconv.Atoi(q.Get("page"))
+	filter.PageSize, _ = strconv.Atoi(q.Get("page_size"))
+	filter = normalizePaging(filter)
+
+	users, total, err := h.service.ListUsers(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "Failed to retrieve users", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(listUsersResponse{Data: users, Total: total, Page: filter.Page, PageSize: filter.PageSize})
+}
+
+func (h *AdminHandler) getUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDFromPath(w, r)
+	if !ok {
+		return
+	}
+	user, err := h.service.GetUser(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve user", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	writeUser(w, user)
+}
+
+func (h *AdminHandler) assignRoleHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, userID, ok := adminAndUserID(w, r)
+	if !ok {
+		return
+	}
+	var req assignRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Role == "" {
+		http.Error(w, "Invalid request body, role is required", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.service.AssignRole(r.Context(), adminID, userID, req.Role)
+	if err != nil {
+		writeAdminError(w, err, "Failed to assign role")
+		return
+	}
+	writeUser(w, user)
+}
+
+func (h *AdminHandler) revokeRoleHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, userID, ok := adminAndUserID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.service.RevokeRole(r.Context(), adminID, userID, mux.Vars(r)["role"])
+	if err != nil {
+		writeAdminError(w, err, "Failed to revoke role")
+		return
+	}
+	writeUser(w, user)
+}
+
+func (h *AdminHandler) deactivateUserHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, userID, ok := adminAndUserID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.service.DeactivateUser(r.Context(), adminID, userID)
+	if err != nil {
+		writeAdminError(w, err, "Failed to deactivate user")
+		return
+	}
+	writeUser(w, user)
+}
+
+func (h *AdminHandler) reactivateUserHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, userID, ok := adminAndUserID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.service.ReactivateUser(r.Context(), adminID, userID)
+	if err != nil {
+		writeAdminError(w, err, "Failed to reactivate user")
+		return
+	}
+	writeUser(w, user)
+}
+
+// unlockUserHandler lets a user who was locked out by failed logins try again immediately.
+func (h *AdminHandler) unlockUserHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, userID, ok := adminAndUserID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.service.UnlockUser(r.Context(), adminID, userID)
+	if err != nil {
+		writeAdminError(w, err, "Failed to unlock user")
+		return
+	}
+	writeUser(w, user)
+}
+
+func (h *AdminHandler) setTwoFactorRequiredHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, userID, ok := adminAndUserID(w, r)
+	if !ok {
+		return
+	}
+	var req setTwoFactorRequiredRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.service.SetTwoFactorRequired(r.Context(), adminID, userID, req.Required)
+	if err != nil {
+		writeAdminError(w, err, "Failed to update two-factor requirement")
+		return
+	}
+	writeUser(w, user)
+}
+
+func (h *AdminHandler) resetTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, userID, ok := adminAndUserID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.service.ResetTwoFactor(r.Context(), adminID, userID)
+	if err != nil {
+		writeAdminError(w, err, "Failed to reset two-factor authentication")
+		return
+	}
+	writeUser(w, user)
+}
+
+func (h *AdminHandler) deleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, userID, ok := adminAndUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteUser(r.Context(), adminID, userID); err != nil {
+		writeAdminError(w, err, "Failed to delete user")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminHandler) resetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, userID, ok := adminAndUserID(w, r)
+	if !ok {
+		return
+	}
+	var req resetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	password, err := h.service.ResetPassword(r.Context(), adminID, userID, req.NewPassword)
+	if err != nil {
+		writeAdminError(w, err, "Failed to reset password")
+		return
+	}
+
+	// Only echo the password back when the admin did not choose it themselves.
+	resp := resetPasswordResponse{}
+	if req.NewPassword == "" {
+		resp.TemporaryPassword = password
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// --- Invitation Handlers ---
+
+func (h *AdminHandler) createInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	var req createInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Email == "" {
+		http.Error(w, "Email is required", http.StatusBadRequest)
+		return
+	}
+	if req.Role == "" {
+		req.Role = domain.RoleStaff
+	}
+
+	invitation, token, err := h.service.CreateInvitation(r.Context(), adminID, req.Email, req.Role)
+	if err != nil {
+		switch err.Error() {
+		case "invitations can only grant the staff or admin role":
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case "user with this email already exists":
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			writeAdminError(w, err, "Failed to create invitation")
+		}
+		return
+	}
+
+	// The raw token is only ever shown here; the admin passes it on to the invitee.
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createInvitationResponse{StaffInvitation: invitation, Token: token})
+}
+
+func (h *AdminHandler) listInvitationsHandler(w http.ResponseWriter, r *http.Request) {
+	invitations, err := h.service.ListPendingInvitations(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to retrieve invitations", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(invitations)
+}
+
+func (h *AdminHandler) revokeInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	invitationID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid invitation ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RevokeInvitation(r.Context(), invitationID); err != nil {
+		switch err.Error() {
+		case "invitation not found":
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case "invitation has already been used":
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to revoke invitation", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// --- Helpers ---
+
+func userIDFromPath(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return userID, true
+}
+
+func adminAndUserID(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	adminID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return 0, 0, false
+	}
+	userID, ok := userIDFromPath(w, r)
+	return adminID, userID, ok
+}
+
+func writeAdminError(w http.ResponseWriter, err error, fallback string) {
+	switch err.Error() {
+	case "user not found", "role not found":
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case "you cannot revoke your own admin role",
+		"you cannot deactivate your own account",
+		"you cannot delete your own account":
+		http.Error(w, err.Error(), http.StatusConflict)
+	case "password must be at least 8 characters",
+		"two-factor authentication can only be required for staff and admins":
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func writeUser(w http.ResponseWriter, user *domain.User) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
+}
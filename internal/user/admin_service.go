@@ -0,0 +1,371 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/securetoken"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	defaultPageSize   = 20
+	maxPageSize       = 100
+	minPasswordLength = 8
+)
+
+// AdminService defines the user management operations available to administrators.
+// Mutating methods take the acting admin's ID: RevokeRole, DeactivateUser and DeleteUser
+// use it so admins cannot lock themselves out, and the methods that write an audit
+// entry record it as the actor.
+type AdminService interface {
+	ListUsers(ctx context.Context, filter ListFilter) ([]*domain.User, int64, error)
+	GetUser(ctx context.Context, userID int64) (*domain.User, error)
+	AssignRole(ctx context.Context, adminID, userID int64, roleName string) (*domain.User, error)
+	RevokeRole(ctx context.Context, adminID, userID int64, roleName string) (*domain.User, error)
+	DeactivateUser(ctx context.Context, adminID, userID int64) (*domain.User, error)
+	ReactivateUser(ctx context.Context, adminID, userID int64) (*domain.User, error)
+	DeleteUser(ctx context.Context, adminID, userID int64) error
+	// UnlockUser lifts a lockout caused by failed logins on the user's account.
+	UnlockUser(ctx context.Context, adminID, userID int64) (*domain.User, error)
+	// SetTwoFactorRequired makes two-factor authentication mandatory (or optional again)
+	// for a staff member or admin. A user it becomes mandatory for must enroll at their next login.
+	SetTwoFactorRequired(ctx context.Context, adminID, userID int64, required bool) (*domain.User, error)
+	// ResetTwoFactor removes a user's authenticator and recovery codes, e.g. after they lost their phone.
+	ResetTwoFactor(ctx context.Context, adminID, userID int64) (*domain.User, error)
+	// ResetPassword sets a new password for the user. If newPassword is empty, a random
+	// temporary password is generated. The password that was set is returned.
+	ResetPassword(ctx context.Context, adminID, userID int64, newPassword string) (string, error)
+
+	// CreateInvitation invites an email address to register with the staff or admin role.
+	// The raw invitation token is returned only here; it is stored hashed.
+	CreateInvitation(ctx context.Context, adminID int64, email, roleName string) (*domain.StaffInvitation, string, error)
+	ListPendingInvitations(ctx context.Context) ([]*domain.StaffInvitation, error)
+	RevokeInvitation(ctx context.Context, invitationID int64) error
+}
+
+type adminService struct {
+	userRepo      Repository
+	auditor       Auditor
+	invitationTTL time.Duration
+}
+
+// NewAdminService creates a new instance of the admin user service.
+// Staff invitations it creates are valid for invitationTTL.
+func NewAdminService(repo Repository, auditor Auditor, invitationTTL time.Duration) AdminService {
+	return &adminService{
+		userRepo:      repo,
+		auditor:       auditor,
+		invitationTTL: invitationTTL,
+	}
+}
+
+func (s *adminService) ListUsers(ctx context.Context, filter ListFilter) ([]*domain.User, int64, error) {
+	return s.userRepo.ListUsers(ctx, normalizePaging(filter))
+}
+
+func (s *adminService) GetUser(ctx context.Context, userID int64) (*domain.User, error) {
+	return s.userRepo.GetUserProfile(ctx, userID)
+}
+
+func (s *adminService) AssignRole(ctx context.Context, adminID, userID int64, roleName string) (*domain.User, error) {
+	user, role, err := s.userAndRole(ctx, userID, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if hasRole(user, roleName) {
+		return user, nil
+	}
+	if err := s.userRepo.AssignRole(ctx, user, role); err != nil {
+		return nil, err
+	}
+	return s.userRepo.GetUserProfile(ctx, userID)
+}
+
+func (s *adminService) RevokeRole(ctx context.Context, adminID, userID int64, roleName string) (*domain.User, error) {
+	if adminID == userID && roleName == domain.RoleAdmin {
+		return nil, errors.New("you cannot revoke your own admin role")
+	}
+	user, role, err := s.userAndRole(ctx, userID, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if !hasRole(user, roleName) {
+		return user, nil
+	}
+	if err := s.userRepo.RevokeRole(ctx, user, role); err != nil {
+		return nil, err
+	}
+	return s.userRepo.GetUserProfile(ctx, userID)
+}
+
+func (s *adminService) DeactivateUser(ctx context.Context, adminID, userID int64) (*domain.User, error) {
+	if adminID == userID {
+		return nil, errors.New("you cannot deactivate your own account")
+	}
+	user, err := s.existingUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeactivatedAt == nil {
+		now := time.Now()
+		user.DeactivatedAt = &now
+		if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+	// Log the user out everywhere so their refresh tokens stop working too.
+	if err := s.userRepo.RevokeUserSessions(ctx, userID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *adminService) ReactivateUser(ctx context.Context, adminID, userID int64) (*domain.User, error) {
+	user, err := s.existingUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeactivatedAt != nil {
+		user.DeactivatedAt = nil
+		if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+func (s *adminService) DeleteUser(ctx context.Context, adminID, userID int64) error {
+	if adminID == userID {
+		return errors.New("you cannot delete your own account")
+	}
+	if _, err := s.existingUser(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.userRepo.RevokeUserSessions(ctx, userID); err != nil {
+		return err
+	}
+	return s.userRepo.DeleteUser(ctx, userID)
+}
+
+func (s *adminService) UnlockUser(ctx context.Context, adminID, userID int64) (*domain.User, error) {
+	user, err := s.existingUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.DeleteLoginThrottle(ctx, accountThrottleKey(user.Email)); err != nil {
+		return nil, err
+	}
+	if err := s.record(ctx, adminID, domain.AuditActionLoginUnlocked, user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *adminService) SetTwoFactorRequired(ctx context.Context, adminID, userID int64, required bool) (*domain.User, error) {
+	user, err := s.existingUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if required && !hasRole(user, domain.RoleStaff) && !hasRole(user, domain.RoleAdmin) {
+		return nil, errors.New("two-factor authentication can only be required for staff and admins")
+	}
+	if user.TwoFactorRequired == required {
+		return user, nil
+	}
+
+	user.TwoFactorRequired = required
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	action := domain.AuditActionTwoFactorOptional
+	if required {
+		action = domain.AuditActionTwoFactorRequired
+	}
+	if err := s.record(ctx, adminID, action, user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *adminService) ResetTwoFactor(ctx context.Context, adminID, userID int64) (*domain.User, error) {
+	user, err := s.existingUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	user.TOTPSecret = ""
+	user.TOTPEnabledAt = nil
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.ReplaceRecoveryCodes(ctx, user.ID, nil); err != nil {
+		return nil, err
+	}
+	if err := s.record(ctx, adminID, domain.AuditActionTwoFactorReset, user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *adminService) ResetPassword(ctx context.Context, adminID, userID int64, newPassword string) (string, error) {
+	if newPassword == "" {
+		generated, err := generateTemporaryPassword()
+		if err != nil {
+			return "", err
+		}
+		newPassword = generated
+	}
+	if len(newPassword) < minPasswordLength {
+		return "", errors.New("password must be at least 8 characters")
+	}
+
+	user, err := s.existingUser(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	user.PasswordHash = string(hashedPassword)
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		return "", err
+	}
+	// Anyone holding a session under the old password is logged out.
+	if err := s.userRepo.RevokeUserSessions(ctx, userID); err != nil {
+		return "", err
+	}
+	return newPassword, nil
+}
+
+func (s *adminService) CreateInvitation(ctx context.Context, adminID int64, email, roleName string) (*domain.StaffInvitation, string, error) {
+	if roleName != domain.RoleStaff && roleName != domain.RoleAdmin {
+		return nil, "", errors.New("invitations can only grant the staff or admin role")
+	}
+	existingUser, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, "", err
+	}
+	if existingUser != nil {
+		return nil, "", errors.New("user with this email already exists")
+	}
+	role, err := s.userRepo.GetRoleByName(ctx, roleName)
+	if err != nil {
+		return nil, "", err
+	}
+	if role == nil {
+		return nil, "", errors.New("role not found")
+	}
+
+	token, err := securetoken.Generate()
+	if err != nil {
+		return nil, "", err
+	}
+	invitation := &domain.StaffInvitation{
+		Email:       email,
+		RoleID:      role.ID,
+		TokenHash:   securetoken.Hash(token),
+		InvitedByID: adminID,
+		ExpiresAt:   time.Now().Add(s.invitationTTL),
+	}
+	if err := s.userRepo.CreateInvitation(ctx, invitation); err != nil {
+		return nil, "", err
+	}
+	invitation.Role = role
+	return invitation, token, nil
+}
+
+func (s *adminService) ListPendingInvitations(ctx context.Context) ([]*domain.StaffInvitation, error) {
+	return s.userRepo.ListPendingInvitations(ctx, time.Now())
+}
+
+func (s *adminService) RevokeInvitation(ctx context.Context, invitationID int64) error {
+	invitation, err := s.userRepo.GetInvitationByID(ctx, invitationID)
+	if err != nil {
+		return err
+	}
+	if invitation == nil {
+		return errors.New("invitation not found")
+	}
+	if invitation.AcceptedAt != nil {
+		return errors.New("invitation has already been used")
+	}
+	if invitation.RevokedAt == nil {
+		now := time.Now()
+		invitation.RevokedAt = &now
+		return s.userRepo.UpdateInvitation(ctx, invitation)
+	}
+	return nil
+}
+
+func (s *adminService) existingUser(ctx context.Context, userID int64) (*domain.User, error) {
+	user, err := s.userRepo.GetUserProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (s *adminService) userAndRole(ctx context.Context, userID int64, roleName string) (*domain.User, *domain.Role, error) {
+	user, err := s.existingUser(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	role, err := s.userRepo.GetRoleByName(ctx, roleName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if role == nil {
+		return nil, nil, errors.New("role not found")
+	}
+	return user, role, nil
+}
+
+// record writes an audit entry for an admin action on a user.
+func (s *adminService) record(ctx context.Context, adminID int64, action domain.AuditAction, userID int64) error {
+	return s.auditor.Record(ctx, &domain.AuditLog{
+		ActorID:    &adminID,
+		Action:     action,
+		TargetType: "user",
+		TargetID:   &userID,
+	})
+}
+
+// normalizePaging fills in the default page and clamps the page size.
+func normalizePaging(filter ListFilter) ListFilter {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultPageSize
+	}
+	if filter.PageSize > maxPageSize {
+		filter.PageSize = maxPageSize
+	}
+	return filter
+}
+
+func hasRole(user *domain.User, roleName string) bool {
+	for _, role := range user.Roles {
+		if role.Name == roleName {
+			return true
+		}
+	}
+	return false
+}
+
+// generateTemporaryPassword returns a random 16-character URL-safe password.
+func generateTemporaryPassword() (string, error) {
+	raw := make([]byte, 12)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
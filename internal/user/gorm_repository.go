@@ -2,8 +2,12 @@ package user
 
 import (
 	"context"
+	"errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/likepattern"
+	"time"
 )
 
 // gormRepository is the GORM implementation of the user.Repository interface.
@@ -31,6 +35,18 @@ func (r *gormRepository) CreateUser(ctx context.Context, user *domain.User) erro
 	})
 }
 
+func (r *gormRepository) GetUserByID(ctx context.Context, id int64) (*domain.User, error) {
+	var user domain.User
+	err := r.db.WithContext(ctx).Preload("Roles").First(&user, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *gormRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
 	var user domain.User
 	// THE FIX: Preload("Roles") tells GORM to also fetch the associated roles for this user.
@@ -57,3 +73,372 @@ func (r *gormRepository) GetRoleByName(ctx context.Context, roleName string) (*d
 	}
 	return &role, nil
 }
+
+func (r *gormRepository) ListUsers(ctx context.Context, filter ListFilter) ([]*domain.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.User{})
+
+	if filter.Search != "" {
+		like := likepattern.Contains(filter.Search)
+		query = query.Where(`(full_name ILIKE ? ESCAPE '\' OR email ILIKE ? ESCAPE '\' OR phone_number ILIKE ? ESCAPE '\')`, like, like, like)
+	}
+	if filter.Role != "" {
+		holders := r.db.Table("user_roles").
+			Select("user_roles.user_id").
+			Joins("JOIN roles ON roles.id = user_roles.role_id").
+			Where("roles.name = ?", filter.Role)
+		query = query.Where("id IN (?)", holders)
+	}
+	switch filter.Status {
+	case "active":
+		query = query.Where("deactivated_at IS NULL")
+	case "deactivated":
+		query = query.Where("deactivated_at IS NOT NULL")
+	case "deletion_requested":
+		query = query.Where("deletion_requested_at IS NOT NULL")
+	}
+
+	// A new session lets the same filtered query be used for both the count and the page.
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []*domain.User
+	err := query.Preload("Roles").
+		Order("created_at desc").
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&users).Error
+	return users, total, err
+}
+
+func (r *gormRepository) GetUserProfile(ctx context.Context, id int64) (*domain.User, error) {
+	var user domain.User
+	err := r.db.WithContext(ctx).Preload("Roles").Preload("PaymentMethods").First(&user, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUser omits associations so a partially loaded Roles slice can never rewrite user_roles.
+func (r *gormRepository) UpdateUser(ctx context.Context, user *domain.User) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(user).Error
+}
+
+func (r *gormRepository) AssignRole(ctx context.Context, user *domain.User, role *domain.Role) error {
+	return r.db.WithContext(ctx).Model(user).Association("Roles").Append(role)
+}
+
+func (r *gormRepository) RevokeRole(ctx context.Context, user *domain.User, role *domain.Role) error {
+	return r.db.WithContext(ctx).Model(user).Association("Roles").Delete(role)
+}
+
+// DeleteUser soft-deletes the user; domain.User has gorm.DeletedAt.
+func (r *gormRepository) DeleteUser(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Delete(&domain.User{}, id).Error
+}
+
+func (r *gormRepository) CreateInvitation(ctx context.Context, invitation *domain.StaffInvitation) error {
+	return r.db.WithContext(ctx).Create(invitation).Error
+}
+
+func (r *gormRepository) GetInvitationByID(ctx context.Context, id int64) (*domain.StaffInvitation, error) {
+	var invitation domain.StaffInvitation
+	err := r.db.WithContext(ctx).Preload("Role").First(&invitation, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *gormRepository) GetInvitationByTokenHash(ctx context.Context, tokenHash string) (*domain.StaffInvitation, error) {
+	var invitation domain.StaffInvitation
+	err := r.db.WithContext(ctx).Preload("Role").Where("token_hash = ?", tokenHash).First(&invitation).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *gormRepository) ListPendingInvitations(ctx context.Context, now time.Time) ([]*domain.StaffInvitation, error) {
+	var invitations []*domain.StaffInvitation
+	err := r.db.WithContext(ctx).
+		Preload("Role").
+		Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", now).
+		Order("created_at desc").
+		Find(&invitations).Error
+	return invitations, err
+}
+
+func (r *gormRepository) UpdateInvitation(ctx context.Context, invitation *domain.StaffInvitation) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(invitation).Error
+}
+
+func (r *gormRepository) AcceptInvitation(ctx context.Context, invitation *domain.StaffInvitation, user *domain.User) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Claim the invitation first; the conditional update makes it single-use.
+		now := time.Now()
+		result := tx.Model(&domain.StaffInvitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitation.ID).
+			Update("accepted_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("invitation has already been used")
+		}
+		invitation.AcceptedAt = &now
+
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return tx.Model(user).Association("Roles").Append(user.Roles)
+	})
+}
+
+func (r *gormRepository) CreateSession(ctx context.Context, session *domain.UserSession) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *gormRepository) GetSessionByID(ctx context.Context, id int64) (*domain.UserSession, error) {
+	var session domain.UserSession
+	err := r.db.WithContext(ctx).First(&session, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *gormRepository) GetSessionByRefreshTokenHash(ctx context.Context, tokenHash string) (*domain.UserSession, error) {
+	var session domain.UserSession
+	err := r.db.WithContext(ctx).
+		Where("refresh_token_hash = ? OR previous_token_hash = ?", tokenHash, tokenHash).
+		First(&session).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RotateSession only updates the row if oldHash is still the current token, so two
+// concurrent refreshes with the same token cannot both succeed.
+func (r *gormRepository) RotateSession(ctx context.Context, sessionID int64, oldHash, newHash string, usedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.UserSession{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", sessionID, oldHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":  newHash,
+			"previous_token_hash": oldHash,
+			"last_used_at":        usedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *gormRepository) RevokeSession(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).Model(&domain.UserSession{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *gormRepository) RevokeUserSessions(ctx context.Context, userID int64) error {
+	return r.db.WithContext(ctx).Model(&domain.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *gormRepository) RevokeOtherSessions(ctx context.Context, userID, keepSessionID int64) error {
+	return r.db.WithContext(ctx).Model(&domain.UserSession{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepSessionID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *gormRepository) CreatePasswordResetToken(ctx context.Context, token *domain.PasswordResetToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Only the most recently requested link works.
+		if err := tx.Model(&domain.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+func (r *gormRepository) GetPasswordResetTokenByHash(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	var token domain.PasswordResetToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *gormRepository) ResetPassword(ctx context.Context, token *domain.PasswordResetToken, passwordHash string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Claim the token first; the conditional update makes it single-use.
+		now := time.Now()
+		result := tx.Model(&domain.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("invalid or expired reset token")
+		}
+		token.UsedAt = &now
+
+		if err := tx.Model(&domain.User{}).Where("id = ?", token.UserID).
+			Update("password_hash", passwordHash).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.UserSession{}).
+			Where("user_id = ? AND revoked_at IS NULL", token.UserID).
+			Update("revoked_at", now).Error
+	})
+}
+
+func (r *gormRepository) CreateEmailVerificationToken(ctx context.Context, token *domain.EmailVerificationToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Only the most recently sent link works.
+		if err := tx.Model(&domain.EmailVerificationToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+func (r *gormRepository) GetEmailVerificationTokenByHash(ctx context.Context, tokenHash string) (*domain.EmailVerificationToken, error) {
+	var token domain.EmailVerificationToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *gormRepository) VerifyEmail(ctx context.Context, token *domain.EmailVerificationToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&domain.EmailVerificationToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("invalid or expired verification token")
+		}
+		token.UsedAt = &now
+
+		return tx.Model(&domain.User{}).
+			Where("id = ? AND verified_at IS NULL", token.UserID).
+			Update("verified_at", now).Error
+	})
+}
+
+func (r *gormRepository) GetLoginThrottle(ctx context.Context, key string) (*domain.LoginThrottle, error) {
+	var throttle domain.LoginThrottle
+	err := r.db.WithContext(ctx).Where("throttle_key = ?", key).First(&throttle).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// CountLoginFailure makes sure the counter row exists, then locks it so concurrent
+// failures for the same key are counted one after another.
+func (r *gormRepository) CountLoginFailure(ctx context.Context, key string, now, resetBefore time.Time) (*domain.LoginThrottle, error) {
+	var throttle domain.LoginThrottle
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		seed := &domain.LoginThrottle{Key: key, LastFailedAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(seed).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("throttle_key = ?", key).First(&throttle).Error; err != nil {
+			return err
+		}
+		if throttle.LastFailedAt.Before(resetBefore) {
+			throttle.FailedAttempts = 0
+			throttle.LockedUntil = nil
+		}
+		throttle.FailedAttempts++
+		throttle.LastFailedAt = now
+		return tx.Save(&throttle).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+func (r *gormRepository) ExtendLoginLockout(ctx context.Context, key string, until time.Time) error {
+	return r.db.WithContext(ctx).Model(&domain.LoginThrottle{}).
+		Where("throttle_key = ? AND (locked_until IS NULL OR locked_until < ?)", key, until).
+		Update("locked_until", until).Error
+}
+
+func (r *gormRepository) DeleteLoginThrottle(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Where("throttle_key = ?", key).Delete(&domain.LoginThrottle{}).Error
+}
+
+func (r *gormRepository) ClaimTOTPStep(ctx context.Context, userID, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *gormRepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codeHashes) == 0 {
+			return nil
+		}
+		codes := make([]*domain.RecoveryCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, &domain.RecoveryCode{UserID: userID, CodeHash: hash})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *gormRepository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
@@ -2,6 +2,9 @@ package user
 
 import (
 	"encoding/json"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/middleware"
+	"net"
 	"net/http"
 	"time"
 
@@ -19,17 +22,41 @@ func NewHandler(s Service) *Handler {
 }
 
 // RegisterRoutes sets up the routing for the user feature.
-func (h *Handler) RegisterRoutes(router *mux.Router) {
+func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler, authz *middleware.Authorizer) {
 	// We are creating a subrouter for staff-related endpoints for better organization.
 	staffRouter := router.PathPrefix("/api/staff").Subrouter()
+	// Staff registration is invitation-only: the token issued by an admin authorizes it.
+	staffRouter.HandleFunc("/invitations/{token:[0-9a-f]+}", h.getInvitationHandler).Methods("GET")
 	staffRouter.HandleFunc("/register", h.registerStaffHandler).Methods("POST")
 	staffRouter.HandleFunc("/login", h.loginStaffHandler).Methods("POST") // Add the new login route
+	// Second login step for staff with two-factor authentication.
+	staffRouter.HandleFunc("/login/2fa", h.completeStaffLoginHandler).Methods("POST")
+	staffRouter.HandleFunc("/login/2fa/setup", h.setupTwoFactorForLoginHandler).Methods("POST")
+
+	// Two-factor self-service for logged-in staff and admins.
+	twoFactor := staffRouter.PathPrefix("/2fa").Subrouter()
+	twoFactor.Use(authMiddleware, authz.Require(domain.RoleStaff, domain.RoleAdmin))
+	twoFactor.HandleFunc("/setup", h.setupTwoFactorHandler).Methods("POST")
+	twoFactor.HandleFunc("/enable", h.enableTwoFactorHandler).Methods("POST")
+	twoFactor.HandleFunc("/disable", h.disableTwoFactorHandler).Methods("POST")
+	twoFactor.HandleFunc("/recovery-codes", h.regenerateRecoveryCodesHandler).Methods("POST")
 
 	// Customer routes
 	customerRouter := router.PathPrefix("/api/customers").Subrouter()
 	customerRouter.HandleFunc("/register", h.registerCustomerHandler).Methods("POST")
 	// We can reuse the login handler for customers, as the logic is identical.
 	customerRouter.HandleFunc("/login", h.loginCustomerHandler).Methods("POST")
+	customerRouter.HandleFunc("/verify-email", h.verifyEmailHandler).Methods("POST")
+	// Unverified customers can still log in, so they can ask for a new link.
+	customerRouter.Handle("/verify-email/resend", authMiddleware(http.HandlerFunc(h.resendVerificationHandler))).Methods("POST")
+
+	// Session routes, shared by staff and customers.
+	authRouter := router.PathPrefix("/api/auth").Subrouter()
+	authRouter.HandleFunc("/refresh", h.refreshHandler).Methods("POST")
+	authRouter.HandleFunc("/forgot-password", h.forgotPasswordHandler).Methods("POST")
+	authRouter.HandleFunc("/reset-password", h.resetPasswordHandler).Methods("POST")
+	authRouter.Handle("/logout", authMiddleware(http.HandlerFunc(h.logoutHandler))).Methods("POST")
+	authRouter.Handle("/logout-all", authMiddleware(http.HandlerFunc(h.logoutAllHandler))).Methods("POST")
 }
 
 // --- General Structs (used by both staff and customer) ---
@@ -48,9 +75,36 @@ type loginRequest struct {
 }
 
 type loginResponse struct {
+	Token        string `json:"token"` // Short-lived access token
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // Access token lifetime in seconds
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type verifyEmailRequest struct {
 	Token string `json:"token"`
 }
 
+type forgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type completePasswordResetRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func newLoginResponse(tokens *AuthTokens) loginResponse {
+	return loginResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    int64(tokens.ExpiresIn.Seconds()),
+	}
+}
+
 // --- Customer Handlers ---
 
 type registerCustomerRequest struct {
@@ -99,10 +153,10 @@ func (h *Handler) loginCustomerHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
-	token, err := h.service.LoginCustomer(r.Context(), req.Email, req.Password)
+	tokens, err := h.service.LoginCustomer(r.Context(), req.Email, req.Password, clientIP(r))
 	if err != nil {
 		// Handle specific errors
-		if err.Error() == "access denied: staff cannot log in through customer portal" {
+		if err.Error() == "access denied: staff cannot log in through customer portal" || err.Error() == "account is deactivated" {
 			http.Error(w, err.Error(), http.StatusForbidden)
 			return
 		}
@@ -110,19 +164,72 @@ func (h *Handler) loginCustomerHandler(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
 		}
+		if err.Error() == "too many failed login attempts, try again later" {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
 		http.Error(w, "Login failed", http.StatusInternalServerError)
 		return
 	}
-	json.NewEncoder(w).Encode(loginResponse{Token: token})
+	json.NewEncoder(w).Encode(newLoginResponse(tokens))
+}
+
+func (h *Handler) verifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	var req verifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Token == "" {
+		http.Error(w, "Token is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.VerifyEmail(r.Context(), req.Token); err != nil {
+		if err.Error() == "invalid or expired verification token" {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to verify email", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) resendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.service.ResendVerification(r.Context(), userID); err != nil {
+		switch err.Error() {
+		case "email is already verified":
+			http.Error(w, err.Error(), http.StatusConflict)
+		case "user not found":
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, "Failed to send verification email", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
 }
 
 // --- Staff Handlers ---
 type registerStaffRequest struct {
-	FullName    string `json:"full_name"`
-	Email       string `json:"email"`
-	Password    string `json:"password"`
-	PhoneNumber string `json:"phone_number"`
-	Address     string `json:"address"`
+	InvitationToken string `json:"invitation_token"`
+	FullName        string `json:"full_name"`
+	Password        string `json:"password"`
+	PhoneNumber     string `json:"phone_number"`
+	Address         string `json:"address"`
+}
+
+type invitationResponse struct {
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 // registerStaffHandler handles the staff registration request.
@@ -135,22 +242,25 @@ func (h *Handler) registerStaffHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 2. Basic validation (can be expanded later).
-	if req.Email == "" || req.Password == "" || req.FullName == "" {
-		http.Error(w, "Full name, email, and password are required", http.StatusBadRequest)
+	if req.InvitationToken == "" || req.Password == "" || req.FullName == "" {
+		http.Error(w, "Invitation token, full name, and password are required", http.StatusBadRequest)
 		return
 	}
 
 	// 3. Call the business logic (the service).
 	// We pass the request context, which is important for managing request lifecycle.
-	user, err := h.service.RegisterStaff(r.Context(), req.FullName, req.Email, req.Password, req.PhoneNumber, req.Address)
+	user, err := h.service.RegisterStaff(r.Context(), req.InvitationToken, req.FullName, req.Password, req.PhoneNumber, req.Address)
 	if err != nil {
 		// Check for specific business errors we defined in the service.
-		if err.Error() == "user with this email already exists" {
+		switch err.Error() {
+		case "user with this email already exists", "invitation has already been used":
 			http.Error(w, err.Error(), http.StatusConflict) // 409 Conflict
-			return
+		case "invalid invitation", "invitation has expired":
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			// For any other error, it's a server-side problem.
+			http.Error(w, "Failed to register user", http.StatusInternalServerError)
 		}
-		// For any other error, it's a server-side problem.
-		http.Error(w, "Failed to register user", http.StatusInternalServerError)
 		return
 	}
 
@@ -168,6 +278,33 @@ func (h *Handler) registerStaffHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+// getInvitationHandler shows who an invitation is for, so the registration form can be pre-filled.
+func (h *Handler) getInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	invitation, err := h.service.GetInvitation(r.Context(), mux.Vars(r)["token"])
+	if err != nil {
+		switch err.Error() {
+		case "invalid invitation":
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case "invitation has already been used", "invitation has expired":
+			http.Error(w, err.Error(), http.StatusGone)
+		default:
+			http.Error(w, "Failed to retrieve invitation", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := invitationResponse{
+		Email:     invitation.Email,
+		ExpiresAt: invitation.ExpiresAt,
+	}
+	if invitation.Role != nil {
+		resp.Role = invitation.Role.Name
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
 // loginStaffHandler handles the staff login request.
 func (h *Handler) loginStaffHandler(w http.ResponseWriter, r *http.Request) {
 	var req loginRequest
@@ -175,10 +312,10 @@ func (h *Handler) loginStaffHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
-	token, err := h.service.LoginStaff(r.Context(), req.Email, req.Password)
+	result, err := h.service.LoginStaff(r.Context(), req.Email, req.Password, clientIP(r))
 	if err != nil {
 		// Handle our new authorization error specifically
-		if err.Error() == "access denied: user is not a staff member" {
+		if err.Error() == "access denied: user is not a staff member" || err.Error() == "account is deactivated" {
 			http.Error(w, err.Error(), http.StatusForbidden) // 403 Forbidden is the correct code
 			return
 		}
@@ -186,8 +323,142 @@ func (h *Handler) loginStaffHandler(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
 		}
+		if err.Error() == "too many failed login attempts, try again later" {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
 		http.Error(w, "Login failed", http.StatusInternalServerError)
 		return
 	}
-	json.NewEncoder(w).Encode(loginResponse{Token: token})
+	if result.Tokens == nil {
+		// The password was right; the client must now send a two-factor code.
+		json.NewEncoder(w).Encode(twoFactorChallengeResponse{
+			TwoFactorRequired:  true,
+			ChallengeToken:     result.ChallengeToken,
+			EnrollmentRequired: result.EnrollmentRequired,
+		})
+		return
+	}
+	json.NewEncoder(w).Encode(newLoginResponse(result.Tokens))
+}
+
+// --- Session Handlers ---
+
+// refreshHandler swaps a refresh token for a new token pair. The old refresh token stops working.
+func (h *Handler) refreshHandler(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.RefreshToken == "" {
+		http.Error(w, "Refresh token is required", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.service.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		switch err.Error() {
+		case "invalid refresh token", "refresh token has expired":
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		case "account is deactivated":
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
+		}
+		return
+	}
+	json.NewEncoder(w).Encode(newLoginResponse(tokens))
+}
+
+// logoutHandler ends the session the request was made with.
+func (h *Handler) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	sessionID, ok := middleware.SessionIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Could not retrieve session from token", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.service.Logout(r.Context(), userID, sessionID); err != nil {
+		if err.Error() == "session not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to log out", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// logoutAllHandler ends every session of the current user, on all devices.
+func (h *Handler) logoutAllHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.service.LogoutAll(r.Context(), userID); err != nil {
+		http.Error(w, "Failed to log out", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// --- Password Reset Handlers ---
+
+// forgotPasswordHandler always answers 202, whether or not the email has an account.
+func (h *Handler) forgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var req forgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Email == "" {
+		http.Error(w, "Email is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RequestPasswordReset(r.Context(), req.Email); err != nil {
+		http.Error(w, "Failed to request password reset", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) resetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var req completePasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Token == "" || req.Password == "" {
+		http.Error(w, "Token and password are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		switch err.Error() {
+		case "password must be at least 8 characters", "invalid or expired reset token":
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to reset password", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// clientIP returns the address of the client that sent the request.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
 }
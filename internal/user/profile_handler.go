@@ -0,0 +1,170 @@
+package user
+
+import (
+	"encoding/json"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/middleware"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// ProfileHandler holds the dependencies for the account self-service handlers.
+type ProfileHandler struct {
+	service ProfileService
+}
+
+// NewProfileHandler creates a new instance of the profile handler.
+func NewProfileHandler(s ProfileService) *ProfileHandler {
+	return &ProfileHandler{service: s}
+}
+
+// RegisterRoutes sets up the /api/me routes, which always act on the user in the JWT.
+func (h *ProfileHandler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler, authz *middleware.Authorizer) {
+	r := router.PathPrefix("/api/me").Subrouter()
+	r.Use(authMiddleware, authz.Require(domain.RoleCustomer))
+
+	r.HandleFunc("", h.getProfileHandler).Methods("GET")
+	r.HandleFunc("", h.updateProfileHandler).Methods("PUT")
+	r.HandleFunc("/password", h.changePasswordHandler).Methods("PUT")
+	r.HandleFunc("/deletion-request", h.requestDeletionHandler).Methods("POST")
+	r.HandleFunc("/deletion-request", h.cancelDeletionHandler).Methods("DELETE")
+}
+
+type updateProfileRequest struct {
+	FullName    *string `json:"full_name"`
+	PhoneNumber *string `json:"phone_number"`
+	Address     *string `json:"address"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type requestDeletionRequest struct {
+	Password string `json:"password"`
+}
+
+func (h *ProfileHandler) getProfileHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	user, err := h.service.GetProfile(r.Context(), userID)
+	if err != nil {
+		writeProfileError(w, err, "Failed to retrieve profile")
+		return
+	}
+	writeUser(w, user)
+}
+
+func (h *ProfileHandler) updateProfileHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	var req updateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.service.UpdateProfile(r.Context(), userID, ProfileUpdate{
+		FullName:    req.FullName,
+		PhoneNumber: req.PhoneNumber,
+		Address:     req.Address,
+	})
+	if err != nil {
+		writeProfileError(w, err, "Failed to update profile")
+		return
+	}
+	writeUser(w, user)
+}
+
+func (h *ProfileHandler) changePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	sessionID, ok := middleware.SessionIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Could not retrieve session from token", http.StatusInternalServerError)
+		return
+	}
+	var req changePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		http.Error(w, "Current and new password are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.ChangePassword(r.Context(), userID, sessionID, req.CurrentPassword, req.NewPassword); err != nil {
+		writeProfileError(w, err, "Failed to change password")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ProfileHandler) requestDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	var req requestDeletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Password == "" {
+		http.Error(w, "Invalid request body, password is required", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.service.RequestDeletion(r.Context(), userID, req.Password)
+	if err != nil {
+		writeProfileError(w, err, "Failed to request account deletion")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(user)
+}
+
+func (h *ProfileHandler) cancelDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	user, err := h.service.CancelDeletion(r.Context(), userID)
+	if err != nil {
+		writeProfileError(w, err, "Failed to cancel account deletion")
+		return
+	}
+	writeUser(w, user)
+}
+
+// --- Helpers ---
+
+func currentUserID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return 0, false
+	}
+	return userID, true
+}
+
+func writeProfileError(w http.ResponseWriter, err error, fallback string) {
+	switch err.Error() {
+	case "user not found":
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case "full name cannot be empty", "password must be at least 8 characters":
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case "current password is incorrect":
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case "no deletion request is pending":
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
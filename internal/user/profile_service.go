@@ -0,0 +1,135 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"mobigo-backend/internal/domain"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ProfileUpdate holds the profile fields a user may change. Nil fields are left as they are.
+type ProfileUpdate struct {
+	FullName    *string
+	PhoneNumber *string
+	Address     *string
+}
+
+// ProfileService defines the operations users perform on their own account.
+type ProfileService interface {
+	// GetProfile returns the user with their roles and saved payment methods.
+	GetProfile(ctx context.Context, userID int64) (*domain.User, error)
+	UpdateProfile(ctx context.Context, userID int64, update ProfileUpdate) (*domain.User, error)
+	// ChangePassword checks the current password, sets the new one and logs out every
+	// other session, keeping the one the request was made with.
+	ChangePassword(ctx context.Context, userID, sessionID int64, currentPassword, newPassword string) error
+	// RequestDeletion records that the user wants their account deleted. The password is
+	// asked for again so a stolen session cannot be used to do this.
+	RequestDeletion(ctx context.Context, userID int64, password string) (*domain.User, error)
+	// CancelDeletion withdraws a pending deletion request.
+	CancelDeletion(ctx context.Context, userID int64) (*domain.User, error)
+}
+
+type profileService struct {
+	userRepo Repository
+}
+
+// NewProfileService creates a new instance of the profile service.
+func NewProfileService(repo Repository) ProfileService {
+	return &profileService{userRepo: repo}
+}
+
+func (s *profileService) GetProfile(ctx context.Context, userID int64) (*domain.User, error) {
+	user, err := s.userRepo.GetUserProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (s *profileService) UpdateProfile(ctx context.Context, userID int64, update ProfileUpdate) (*domain.User, error) {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.FullName != nil {
+		if *update.FullName == "" {
+			return nil, errors.New("full name cannot be empty")
+		}
+		user.FullName = *update.FullName
+	}
+	if update.PhoneNumber != nil {
+		user.PhoneNumber = *update.PhoneNumber
+	}
+	if update.Address != nil {
+		user.Address = *update.Address
+	}
+
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *profileService) ChangePassword(ctx context.Context, userID, sessionID int64, currentPassword, newPassword string) error {
+	if len(newPassword) < minPasswordLength {
+		return errors.New("password must be at least 8 characters")
+	}
+
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(currentPassword)); err != nil {
+		return errors.New("current password is incorrect")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = string(hashedPassword)
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		return err
+	}
+	return s.userRepo.RevokeOtherSessions(ctx, userID, sessionID)
+}
+
+func (s *profileService) RequestDeletion(ctx context.Context, userID int64, password string) (*domain.User, error) {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, errors.New("current password is incorrect")
+	}
+
+	if user.DeletionRequestedAt == nil {
+		now := time.Now()
+		user.DeletionRequestedAt = &now
+		if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+func (s *profileService) CancelDeletion(ctx context.Context, userID int64) (*domain.User, error) {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletionRequestedAt == nil {
+		return nil, errors.New("no deletion request is pending")
+	}
+	user.DeletionRequestedAt = nil
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -3,14 +3,102 @@ package user
 import (
 	"context"
 	"mobigo-backend/internal/domain"
+	"time"
 )
 
+// ListFilter narrows down the admin user list. Zero values are ignored.
+type ListFilter struct {
+	Search   string // Matches name, email or phone number
+	Role     string // Only users holding this role
+	Status   string // "active", "deactivated" or "deletion_requested"
+	Page     int
+	PageSize int
+}
+
 // Repository is the interface that provides user storage methods.
 type Repository interface {
 	// CreateUser saves a new user to the database.
 	CreateUser(ctx context.Context, user *domain.User) error
+	// GetUserByID finds a user by ID, with their roles loaded.
+	GetUserByID(ctx context.Context, id int64) (*domain.User, error)
 	// GetUserByEmail finds a user by their email address.
 	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
 	// GetRoleByName finds a role by its name (e.g., "admin", "staff").
 	GetRoleByName(ctx context.Context, roleName string) (*domain.Role, error)
+	// ListUsers returns one page of users matching the filter, plus the total match count.
+	ListUsers(ctx context.Context, filter ListFilter) ([]*domain.User, int64, error)
+	// GetUserProfile finds a user by ID with their roles and saved payment methods loaded.
+	GetUserProfile(ctx context.Context, id int64) (*domain.User, error)
+	// UpdateUser saves a user's own columns. Role changes go through AssignRole/RevokeRole.
+	UpdateUser(ctx context.Context, user *domain.User) error
+	// AssignRole grants a role to a user.
+	AssignRole(ctx context.Context, user *domain.User, role *domain.Role) error
+	// RevokeRole removes a role from a user.
+	RevokeRole(ctx context.Context, user *domain.User, role *domain.Role) error
+	// DeleteUser soft-deletes a user.
+	DeleteUser(ctx context.Context, id int64) error
+
+	// CreateInvitation saves a new staff invitation.
+	CreateInvitation(ctx context.Context, invitation *domain.StaffInvitation) error
+	// GetInvitationByID finds an invitation by ID, with its role loaded.
+	GetInvitationByID(ctx context.Context, id int64) (*domain.StaffInvitation, error)
+	// GetInvitationByTokenHash finds an invitation by the hash of its token, with its role loaded.
+	GetInvitationByTokenHash(ctx context.Context, tokenHash string) (*domain.StaffInvitation, error)
+	// ListPendingInvitations returns invitations that are neither accepted, revoked nor expired.
+	ListPendingInvitations(ctx context.Context, now time.Time) ([]*domain.StaffInvitation, error)
+	// UpdateInvitation saves changes to an invitation.
+	UpdateInvitation(ctx context.Context, invitation *domain.StaffInvitation) error
+	// AcceptInvitation creates the invited user and marks the invitation accepted in one
+	// transaction. It fails if the invitation was accepted or revoked concurrently.
+	AcceptInvitation(ctx context.Context, invitation *domain.StaffInvitation, user *domain.User) error
+
+	// CreateSession saves a new login session.
+	CreateSession(ctx context.Context, session *domain.UserSession) error
+	// GetSessionByID finds a session by ID.
+	GetSessionByID(ctx context.Context, id int64) (*domain.UserSession, error)
+	// GetSessionByRefreshTokenHash finds the session whose current or previous refresh token has this hash.
+	GetSessionByRefreshTokenHash(ctx context.Context, tokenHash string) (*domain.UserSession, error)
+	// RotateSession replaces a session's refresh token. It returns false if the old token
+	// was no longer current, i.e. another request rotated or revoked it first.
+	RotateSession(ctx context.Context, sessionID int64, oldHash, newHash string, usedAt time.Time) (bool, error)
+	// RevokeSession revokes a single session.
+	RevokeSession(ctx context.Context, id int64) error
+	// RevokeUserSessions revokes every active session a user has.
+	RevokeUserSessions(ctx context.Context, userID int64) error
+	// RevokeOtherSessions revokes every active session a user has except keepSessionID.
+	RevokeOtherSessions(ctx context.Context, userID, keepSessionID int64) error
+
+	// CreatePasswordResetToken saves a new reset token, invalidating any earlier unused ones for the user.
+	CreatePasswordResetToken(ctx context.Context, token *domain.PasswordResetToken) error
+	// GetPasswordResetTokenByHash finds a reset token by the hash of its value.
+	GetPasswordResetTokenByHash(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error)
+	// ResetPassword uses up the reset token, sets the new password hash and revokes all of
+	// the user's sessions in one transaction. It fails if the token was used concurrently.
+	ResetPassword(ctx context.Context, token *domain.PasswordResetToken, passwordHash string) error
+
+	// CreateEmailVerificationToken saves a new verification token, invalidating any earlier unused ones for the user.
+	CreateEmailVerificationToken(ctx context.Context, token *domain.EmailVerificationToken) error
+	// GetEmailVerificationTokenByHash finds a verification token by the hash of its value.
+	GetEmailVerificationTokenByHash(ctx context.Context, tokenHash string) (*domain.EmailVerificationToken, error)
+	// VerifyEmail uses up the verification token and marks the user verified in one transaction.
+	VerifyEmail(ctx context.Context, token *domain.EmailVerificationToken) error
+
+	// GetLoginThrottle finds the failed-login counter for a key.
+	GetLoginThrottle(ctx context.Context, key string) (*domain.LoginThrottle, error)
+	// CountLoginFailure atomically adds a failure to the counter for a key, creating it
+	// if needed and starting over if the last failure is older than resetBefore.
+	// It returns the counter after the increment.
+	CountLoginFailure(ctx context.Context, key string, now, resetBefore time.Time) (*domain.LoginThrottle, error)
+	// ExtendLoginLockout locks a key until the given time unless it is already locked for longer.
+	ExtendLoginLockout(ctx context.Context, key string, until time.Time) error
+	// DeleteLoginThrottle clears the failed-login counter for a key.
+	DeleteLoginThrottle(ctx context.Context, key string) error
+
+	// ClaimTOTPStep records that a TOTP code for step was used. It returns false if a
+	// code for this or a later step was already accepted, so each code works once.
+	ClaimTOTPStep(ctx context.Context, userID, step int64) (bool, error)
+	// ReplaceRecoveryCodes deletes a user's recovery codes and saves new ones in one transaction.
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error
+	// UseRecoveryCode marks an unused recovery code as used. It returns false if there is no such code.
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error)
 }
@@ -3,7 +3,11 @@ package user
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
 	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/mailer"
+	"mobigo-backend/pkg/securetoken"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -12,25 +16,90 @@ import (
 
 // Service defines the business logic operations for users.
 type Service interface {
-	RegisterStaff(ctx context.Context, fullName, email, password, phoneNumber, address string) (*domain.User, error)
+	// RegisterStaff completes registration for someone holding a staff invitation token.
+	RegisterStaff(ctx context.Context, invitationToken, fullName, password, phoneNumber, address string) (*domain.User, error)
+	// GetInvitation returns the pending invitation for a token, so the registration form can show its email and role.
+	GetInvitation(ctx context.Context, invitationToken string) (*domain.StaffInvitation, error)
 	RegisterCustomer(ctx context.Context, fullName, email, password, phoneNumber string) (*domain.User, error)
-	// We now have two distinct login methods.
-	LoginStaff(ctx context.Context, email, password string) (string, error)
-	LoginCustomer(ctx context.Context, email, password string) (string, error)
+	// We now have two distinct login methods. Each starts a new session. ipAddress is
+	// the client's address, used to throttle repeated failures.
+	// Staff with two-factor authentication get a challenge token instead of a session,
+	// which CompleteStaffLogin exchanges for tokens given a valid code.
+	LoginStaff(ctx context.Context, email, password, ipAddress string) (*StaffLoginResult, error)
+	LoginCustomer(ctx context.Context, email, password, ipAddress string) (*AuthTokens, error)
+	CompleteStaffLogin(ctx context.Context, challengeToken, code, ipAddress string) (*AuthTokens, []string, error)
+	SetupTwoFactorForLogin(ctx context.Context, challengeToken string) (*TOTPSetup, error)
+	// Two-factor self-service for logged-in staff.
+	SetupTwoFactor(ctx context.Context, userID int64) (*TOTPSetup, error)
+	EnableTwoFactor(ctx context.Context, userID int64, code string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, userID int64, password string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error)
+	// Refresh exchanges a refresh token for a new access token and a new refresh token.
+	Refresh(ctx context.Context, refreshToken string) (*AuthTokens, error)
+	// Logout revokes a single session; LogoutAll revokes every session of a user.
+	Logout(ctx context.Context, userID, sessionID int64) error
+	LogoutAll(ctx context.Context, userID int64) error
+	// RequestPasswordReset emails a reset link if the email belongs to an account.
+	// It reports success either way, so it cannot be used to discover accounts.
+	RequestPasswordReset(ctx context.Context, email string) error
+	// ResetPassword sets a new password using an emailed reset token and logs the user out everywhere.
+	ResetPassword(ctx context.Context, resetToken, newPassword string) error
+	// VerifyEmail confirms a user's email address using an emailed verification token.
+	VerifyEmail(ctx context.Context, verificationToken string) error
+	// ResendVerification emails a fresh verification link to a user who is not yet verified.
+	ResendVerification(ctx context.Context, userID int64) error
+	// IsEmailVerified reports whether a user has confirmed their email address.
+	IsEmailVerified(ctx context.Context, userID int64) (bool, error)
+	// IsSessionActive reports whether an access token's session is still valid.
+	IsSessionActive(ctx context.Context, userID, sessionID int64) (bool, error)
+	// GetUserRoleNames returns the names of the roles a user currently holds.
+	// A user that no longer exists holds no roles.
+	GetUserRoleNames(ctx context.Context, userID int64) ([]string, error)
+}
+
+// Auditor records security-relevant events, such as account lockouts.
+type Auditor interface {
+	Record(ctx context.Context, entry *domain.AuditLog) error
+}
+
+// TokenConfig controls how long issued tokens stay valid.
+type TokenConfig struct {
+	AccessTokenTTL     time.Duration // Lifetime of each access token (JWT)
+	StaffSessionTTL    time.Duration // How long a staff login lasts before logging in again
+	CustomerSessionTTL time.Duration // How long a customer login lasts before logging in again
+	PasswordResetTTL   time.Duration // How long an emailed password reset link works
+	PasswordResetURL   string        // Frontend page the reset link points to; the token is appended as ?token=
+	VerificationTTL    time.Duration // How long an emailed verification link works
+	VerificationURL    string        // Frontend page the verification link points to; the token is appended as ?token=
+}
+
+// AuthTokens is what a successful login or refresh hands back to the client.
+type AuthTokens struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration // Lifetime of the access token
 }
 
 // service is the implementation of the Service interface.
 type service struct {
 	userRepo       Repository
 	jwtSecret      []byte // Add a field for the JWT secret key
+	tokens         TokenConfig
+	lockout        LockoutPolicy
+	mailer         mailer.Mailer
+	auditor        Auditor
 	contextTimeout time.Duration
 }
 
 // NewService creates a new instance of the user service.
-func NewService(repo Repository, jwtSecret string, timeout time.Duration) Service {
+func NewService(repo Repository, jwtSecret string, tokens TokenConfig, lockout LockoutPolicy, mail mailer.Mailer, auditor Auditor, timeout time.Duration) Service {
 	return &service{
 		userRepo:       repo,
 		jwtSecret:      []byte(jwtSecret), // Store the secret as a byte slice
+		tokens:         tokens,
+		lockout:        lockout,
+		mailer:         mail,
+		auditor:        auditor,
 		contextTimeout: timeout,
 	}
 }
@@ -50,7 +119,7 @@ func (s *service) RegisterCustomer(ctx context.Context, fullName, email, passwor
 		return nil, err
 	}
 
-	customerRole, err := s.userRepo.GetRoleByName(ctx, "customer")
+	customerRole, err := s.userRepo.GetRoleByName(ctx, domain.RoleCustomer)
 	if err != nil {
 		return nil, err
 	}
@@ -70,13 +139,25 @@ func (s *service) RegisterCustomer(ctx context.Context, fullName, email, passwor
 	if err != nil {
 		return nil, err
 	}
+
+	// The account exists either way; if the email fails the customer can ask for it again.
+	if err := s.sendVerificationEmail(ctx, newUser); err != nil {
+		log.Printf("MAIL ERROR: Failed to send verification email to user %d: %v", newUser.ID, err)
+	}
 	return newUser, nil
 }
 
-// RegisterStaff handles the business logic for creating a new staff user.
-func (s *service) RegisterStaff(ctx context.Context, fullName, email, password, phoneNumber, address string) (*domain.User, error) {
-	// 1. Check if user with the same email already exists.
-	existingUser, err := s.userRepo.GetUserByEmail(ctx, email)
+// RegisterStaff handles the business logic for creating a new staff user from an invitation.
+// The email and role come from the invitation, not from the registrant.
+func (s *service) RegisterStaff(ctx context.Context, invitationToken, fullName, password, phoneNumber, address string) (*domain.User, error) {
+	// 1. Make sure the invitation is still usable.
+	invitation, err := s.GetInvitation(ctx, invitationToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Check if a user with the invited email already exists.
+	existingUser, err := s.userRepo.GetUserByEmail(ctx, invitation.Email)
 	if err != nil {
 		// This is a system error (e.g., database down).
 		return nil, err
@@ -86,35 +167,27 @@ func (s *service) RegisterStaff(ctx context.Context, fullName, email, password,
 		return nil, errors.New("user with this email already exists")
 	}
 
-	// 2. Hash the password for security.
+	// 3. Hash the password for security.
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, err
 	}
 
-	// 3. Get the 'staff' role from the database.
-	staffRole, err := s.userRepo.GetRoleByName(ctx, "staff")
-	if err != nil {
-		return nil, err
-	}
-	if staffRole == nil {
-		// This indicates a setup problem - the 'staff' role should always exist.
-		return nil, errors.New("staff role not found in database")
-	}
-
-	// 4. Create the new user domain object.
+	// 4. Create the new user domain object with the invited role.
+	// The invitation was delivered to this email, which proves the registrant owns it.
+	verifiedAt := time.Now()
 	newUser := &domain.User{
 		FullName:     fullName,
-		Email:        email,
+		Email:        invitation.Email,
+		VerifiedAt:   &verifiedAt,
 		PasswordHash: string(hashedPassword),
 		PhoneNumber:  phoneNumber,
 		Address:      address,
-		Roles:        []*domain.Role{staffRole}, // Assign the staff role
+		Roles:        []*domain.Role{invitation.Role},
 	}
 
-	// 5. Save the new user to the database.
-	err = s.userRepo.CreateUser(ctx, newUser)
-	if err != nil {
+	// 5. Save the user and use up the invitation together.
+	if err := s.userRepo.AcceptInvitation(ctx, invitation, newUser); err != nil {
 		return nil, err
 	}
 
@@ -122,24 +195,36 @@ func (s *service) RegisterStaff(ctx context.Context, fullName, email, password,
 	return newUser, nil
 }
 
-// LoginStaff authenticates a user AND authorizes them as a staff member.
-func (s *service) LoginStaff(ctx context.Context, email, password string) (string, error) {
-	// Step 1: Authenticate (check email and password)
-	user, err := s.userRepo.GetUserByEmail(ctx, email)
+// GetInvitation looks up an invitation by its raw token and checks it can still be used.
+func (s *service) GetInvitation(ctx context.Context, invitationToken string) (*domain.StaffInvitation, error) {
+	invitation, err := s.userRepo.GetInvitationByTokenHash(ctx, securetoken.Hash(invitationToken))
 	if err != nil {
-		return "", err // System error
+		return nil, err
 	}
-	if user == nil {
-		return "", errors.New("invalid email or password")
+	if invitation == nil || invitation.RevokedAt != nil {
+		return nil, errors.New("invalid invitation")
+	}
+	if invitation.AcceptedAt != nil {
+		return nil, errors.New("invitation has already been used")
 	}
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return "", errors.New("invalid email or password")
+	if time.Now().After(invitation.ExpiresAt) {
+		return nil, errors.New("invitation has expired")
+	}
+	return invitation, nil
+}
+
+// LoginStaff authenticates a user AND authorizes them as a staff member.
+func (s *service) LoginStaff(ctx context.Context, email, password, ipAddress string) (*StaffLoginResult, error) {
+	// Step 1: Authenticate (check email and password)
+	user, err := s.authenticate(ctx, email, password, ipAddress)
+	if err != nil {
+		return nil, err
 	}
 
 	// Step 2: Authorize (check if the user has a staff or admin role)
 	isStaff := false
 	for _, role := range user.Roles {
-		if role.Name == "staff" || role.Name == "admin" {
+		if role.Name == domain.RoleStaff || role.Name == domain.RoleAdmin {
 			isStaff = true
 			break
 		}
@@ -147,57 +232,333 @@ func (s *service) LoginStaff(ctx context.Context, email, password string) (strin
 
 	if !isStaff {
 		// This is a valid user, but they are not staff. Deny access.
-		return "", errors.New("access denied: user is not a staff member")
+		return nil, errors.New("access denied: user is not a staff member")
 	}
 
-	// Step 3: Generate JWT
-	claims := jwt.MapClaims{
-		"user_id": user.ID,
-		"email":   user.Email,
-		"roles":   user.Roles, // Include roles in the token
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
+	// Step 3: Start a session, or ask for the second factor first
+	return s.startStaffLogin(ctx, user)
+}
+
+// LoginCustomer authenticates a user as a customer.
+func (s *service) LoginCustomer(ctx context.Context, email, password, ipAddress string) (*AuthTokens, error) {
+	// Step 1: Authenticate
+	user, err := s.authenticate(ctx, email, password, ipAddress)
+	if err != nil {
+		return nil, err
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(s.jwtSecret)
+
+	// Step 2: Authorize (ensure they are a customer). The customer portal has no second
+	// factor step, so anyone who also holds a staff or admin role, or who has two-factor
+	// authentication on their account, must use the staff portal instead.
+	isCustomer := false
+	for _, role := range user.Roles {
+		switch role.Name {
+		case domain.RoleCustomer:
+			isCustomer = true
+		case domain.RoleStaff, domain.RoleAdmin:
+			return nil, errors.New("access denied: staff cannot log in through customer portal")
+		}
+	}
+	if !isCustomer || user.TOTPEnabledAt != nil || user.TwoFactorRequired {
+		return nil, errors.New("access denied: staff cannot log in through customer portal")
+	}
+
+	// Step 3: Start a session and issue its tokens. Customers can have longer sessions.
+	return s.startSession(ctx, user, s.tokens.CustomerSessionTTL)
+}
+
+// Refresh rotates the refresh token: the presented token stops working and a new one
+// is returned. If a token that was already rotated out is presented again, someone
+// else holds a copy of it, so the whole session is revoked.
+func (s *service) Refresh(ctx context.Context, refreshToken string) (*AuthTokens, error) {
+	oldHash := securetoken.Hash(refreshToken)
+	session, err := s.userRepo.GetSessionByRefreshTokenHash(ctx, oldHash)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if session == nil || session.RevokedAt != nil {
+		return nil, errors.New("invalid refresh token")
+	}
+	if session.RefreshTokenHash != oldHash {
+		if err := s.userRepo.RevokeSession(ctx, session.ID); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("invalid refresh token")
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return nil, errors.New("refresh token has expired")
 	}
 
-	return tokenString, nil
+	user, err := s.userRepo.GetUserByID(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.DeactivatedAt != nil {
+		if err := s.userRepo.RevokeSession(ctx, session.ID); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("account is deactivated")
+	}
+
+	newRefreshToken, err := securetoken.Generate()
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := s.userRepo.RotateSession(ctx, session.ID, oldHash, securetoken.Hash(newRefreshToken), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// Another request used the same token first; treat it like reuse.
+		if err := s.userRepo.RevokeSession(ctx, session.ID); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("invalid refresh token")
+	}
+
+	accessToken, err := s.issueAccessToken(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthTokens{AccessToken: accessToken, RefreshToken: newRefreshToken, ExpiresIn: s.tokens.AccessTokenTTL}, nil
 }
 
-// LoginCustomer authenticates a user as a customer.
-func (s *service) LoginCustomer(ctx context.Context, email, password string) (string, error) {
-	// Step 1: Authenticate
+// Logout revokes the given session, as long as it belongs to the user.
+func (s *service) Logout(ctx context.Context, userID, sessionID int64) error {
+	session, err := s.userRepo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil || session.UserID != userID {
+		return errors.New("session not found")
+	}
+	return s.userRepo.RevokeSession(ctx, sessionID)
+}
+
+// LogoutAll revokes every session the user has, on every device.
+func (s *service) LogoutAll(ctx context.Context, userID int64) error {
+	return s.userRepo.RevokeUserSessions(ctx, userID)
+}
+
+// RequestPasswordReset issues a new reset token and emails it. Unknown and deactivated
+// accounts are silently ignored, and a delivery failure is only logged, so the response
+// never reveals whether an account exists.
+func (s *service) RequestPasswordReset(ctx context.Context, email string) error {
 	user, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
-		return "", err
+		return err
+	}
+	if user == nil || user.DeactivatedAt != nil {
+		return nil
+	}
+
+	rawToken, err := securetoken.Generate()
+	if err != nil {
+		return err
+	}
+	resetToken := &domain.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: securetoken.Hash(rawToken),
+		ExpiresAt: time.Now().Add(s.tokens.PasswordResetTTL),
+	}
+	if err := s.userRepo.CreatePasswordResetToken(ctx, resetToken); err != nil {
+		return err
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your MobiGo password",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"We received a request to reset your password. Use the link below to choose a new one:\n\n"+
+			"%s?token=%s\n\n"+
+			"The link expires in %s and can only be used once. If you did not ask for this, you can ignore this email.\n",
+			user.FullName, s.tokens.PasswordResetURL, rawToken, s.tokens.PasswordResetTTL),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		log.Printf("MAIL ERROR: Failed to send password reset email to user %d: %v", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword checks the reset token and replaces the user's password. Every existing
+// session is revoked, so whoever might have known the old password is logged out.
+func (s *service) ResetPassword(ctx context.Context, resetToken, newPassword string) error {
+	if len(newPassword) < minPasswordLength {
+		return errors.New("password must be at least 8 characters")
+	}
+
+	token, err := s.userRepo.GetPasswordResetTokenByHash(ctx, securetoken.Hash(resetToken))
+	if err != nil {
+		return err
+	}
+	if token == nil || token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return errors.New("invalid or expired reset token")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return s.userRepo.ResetPassword(ctx, token, string(hashedPassword))
+}
+
+// VerifyEmail marks the token's user as verified.
+func (s *service) VerifyEmail(ctx context.Context, verificationToken string) error {
+	token, err := s.userRepo.GetEmailVerificationTokenByHash(ctx, securetoken.Hash(verificationToken))
+	if err != nil {
+		return err
+	}
+	if token == nil || token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return errors.New("invalid or expired verification token")
+	}
+	return s.userRepo.VerifyEmail(ctx, token)
+}
+
+// ResendVerification issues a new verification link; earlier links stop working.
+func (s *service) ResendVerification(ctx context.Context, userID int64) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
 	}
 	if user == nil {
-		return "", errors.New("invalid email or password")
+		return errors.New("user not found")
 	}
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return "", errors.New("invalid email or password")
+	if user.VerifiedAt != nil {
+		return errors.New("email is already verified")
 	}
+	return s.sendVerificationEmail(ctx, user)
+}
 
-	// Step 2: Authorize (ensure they are a customer)
-	isCustomer := false
-	for _, role := range user.Roles {
-		if role.Name == "customer" {
-			isCustomer = true
-			break
+// IsEmailVerified is checked before customers book vehicles or pay.
+func (s *service) IsEmailVerified(ctx context.Context, userID int64) (bool, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user != nil && user.VerifiedAt != nil, nil
+}
+
+// sendVerificationEmail issues a verification token for the user and emails the link.
+func (s *service) sendVerificationEmail(ctx context.Context, user *domain.User) error {
+	rawToken, err := securetoken.Generate()
+	if err != nil {
+		return err
+	}
+	token := &domain.EmailVerificationToken{
+		UserID:    user.ID,
+		TokenHash: securetoken.Hash(rawToken),
+		ExpiresAt: time.Now().Add(s.tokens.VerificationTTL),
+	}
+	if err := s.userRepo.CreateEmailVerificationToken(ctx, token); err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your MobiGo email address",
+		Body: fmt.Sprintf("Hello %s,\n\n"+
+			"Please confirm your email address to start booking vehicles:\n\n"+
+			"%s?token=%s\n\n"+
+			"The link expires in %s.\n",
+			user.FullName, s.tokens.VerificationURL, rawToken, s.tokens.VerificationTTL),
+	})
+}
+
+// IsSessionActive is checked by the auth middleware on every request, so a revoked
+// session stops working immediately rather than when its access token expires.
+func (s *service) IsSessionActive(ctx context.Context, userID, sessionID int64) (bool, error) {
+	session, err := s.userRepo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+	if session == nil || session.UserID != userID || session.RevokedAt != nil {
+		return false, nil
+	}
+	return time.Now().Before(session.ExpiresAt), nil
+}
+
+// authenticate checks a user's email and password and that the account is usable.
+// Failures count towards locking the account and the client address; see LockoutPolicy.
+func (s *service) authenticate(ctx context.Context, email, password, ipAddress string) (*domain.User, error) {
+	keys := []string{accountThrottleKey(email)}
+	if ipAddress != "" {
+		keys = append(keys, ipThrottleKey(ipAddress))
+	}
+	if err := s.checkLockout(ctx, keys...); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, err // System error
+	}
+	if user == nil || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		if err := s.recordLoginFailure(ctx, email, ipAddress, user); err != nil {
+			return nil, err
 		}
+		return nil, errors.New("invalid email or password")
 	}
-	if !isCustomer {
-		return "", errors.New("access denied: staff cannot log in through customer portal")
+	if user.DeactivatedAt != nil {
+		return nil, errors.New("account is deactivated")
 	}
+	return user, nil
+}
 
-	// Step 3: Generate JWT
+// startSession records a new session lasting ttl and issues its first token pair.
+// It is only reached once every step of the login has passed.
+func (s *service) startSession(ctx context.Context, user *domain.User, ttl time.Duration) (*AuthTokens, error) {
+	// A completed login clears the account's count, but not the address's: one valid
+	// login must not let an address keep guessing at other accounts. A correct password
+	// alone does not, or it would reset the count of wrong two-factor codes.
+	if err := s.userRepo.DeleteLoginThrottle(ctx, accountThrottleKey(user.Email)); err != nil {
+		return nil, err
+	}
+	refreshToken, err := securetoken.Generate()
+	if err != nil {
+		return nil, err
+	}
+	session := &domain.UserSession{
+		UserID:           user.ID,
+		RefreshTokenHash: securetoken.Hash(refreshToken),
+		ExpiresAt:        time.Now().Add(ttl),
+	}
+	if err := s.userRepo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.issueAccessToken(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthTokens{AccessToken: accessToken, RefreshToken: refreshToken, ExpiresIn: s.tokens.AccessTokenTTL}, nil
+}
+
+// issueAccessToken signs a short-lived JWT tied to a session.
+func (s *service) issueAccessToken(user *domain.User, sessionID int64) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": user.ID,
 		"email":   user.Email,
-		"exp":     time.Now().Add(time.Hour * 72).Unix(), // Customers can have longer sessions
+		"roles":   user.Roles, // Informational only; authorization reads roles from the database
+		"sid":     sessionID,
+		"exp":     time.Now().Add(s.tokens.AccessTokenTTL).Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(s.jwtSecret)
 }
+
+// GetUserRoleNames resolves a user's roles from the database for authorization checks.
+func (s *service) GetUserRoleNames(ctx context.Context, userID int64) ([]string, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	// Deactivated users keep their roles on record but may not use them.
+	if user == nil || user.DeactivatedAt != nil {
+		return nil, nil
+	}
+	names := make([]string, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		names = append(names, role.Name)
+	}
+	return names, nil
+}
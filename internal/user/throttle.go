@@ -0,0 +1,102 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mobigo-backend/internal/domain"
+	"strings"
+	"time"
+)
+
+// LockoutPolicy controls how failed logins are throttled. Failures are counted per
+// account and per client address; once either count reaches its limit, further
+// attempts are refused until the lockout ends. Every failure past the limit doubles
+// the lockout, up to MaxLockout.
+type LockoutPolicy struct {
+	AccountMaxAttempts int           // Failed logins allowed per account before it is locked
+	IPMaxAttempts      int           // Failed logins allowed per client address before it is locked
+	BaseLockout        time.Duration // Length of the first lockout
+	MaxLockout         time.Duration // Upper bound on a single lockout
+	ResetAfter         time.Duration // Failures older than this are forgotten
+}
+
+var errTooManyAttempts = errors.New("too many failed login attempts, try again later")
+
+func accountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipThrottleKey(ipAddress string) string {
+	return "ip:" + ipAddress
+}
+
+// checkLockout fails if any of the keys is currently locked.
+func (s *service) checkLockout(ctx context.Context, keys ...string) error {
+	now := time.Now()
+	for _, key := range keys {
+		throttle, err := s.userRepo.GetLoginThrottle(ctx, key)
+		if err != nil {
+			return err
+		}
+		if throttle != nil && throttle.LockedUntil != nil && now.Before(*throttle.LockedUntil) {
+			return errTooManyAttempts
+		}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed login against the account and the client address.
+// user is nil when the email does not belong to any account.
+func (s *service) recordLoginFailure(ctx context.Context, email, ipAddress string, user *domain.User) error {
+	if err := s.countFailure(ctx, accountThrottleKey(email), s.lockout.AccountMaxAttempts, ipAddress, user); err != nil {
+		return err
+	}
+	if ipAddress == "" {
+		return nil
+	}
+	return s.countFailure(ctx, ipThrottleKey(ipAddress), s.lockout.IPMaxAttempts, ipAddress, nil)
+}
+
+func (s *service) countFailure(ctx context.Context, key string, maxAttempts int, ipAddress string, user *domain.User) error {
+	now := time.Now()
+	throttle, err := s.userRepo.CountLoginFailure(ctx, key, now, now.Add(-s.lockout.ResetAfter))
+	if err != nil {
+		return err
+	}
+
+	if throttle.FailedAttempts < maxAttempts {
+		return nil
+	}
+
+	until := now.Add(s.lockoutDuration(throttle.FailedAttempts - maxAttempts))
+	if err := s.userRepo.ExtendLoginLockout(ctx, key, until); err != nil {
+		return err
+	}
+	entry := &domain.AuditLog{
+		Action:    domain.AuditActionLoginLocked,
+		IPAddress: ipAddress,
+		Details:   fmt.Sprintf("%s locked until %s after %d failed attempts", key, until.Format(time.RFC3339), throttle.FailedAttempts),
+	}
+	if user != nil {
+		entry.TargetType = "user"
+		entry.TargetID = &user.ID
+	}
+	if err := s.auditor.Record(ctx, entry); err != nil {
+		log.Printf("AUDIT ERROR: Failed to record lockout of %s: %v", key, err)
+	}
+	return nil
+}
+
+// lockoutDuration doubles BaseLockout for every failure past the limit, capped at MaxLockout.
+func (s *service) lockoutDuration(failuresPastLimit int) time.Duration {
+	d := s.lockout.BaseLockout
+	for i := 0; i < failuresPastLimit && d < s.lockout.MaxLockout; i++ {
+		d *= 2
+	}
+	if d > s.lockout.MaxLockout {
+		d = s.lockout.MaxLockout
+	}
+	return d
+}
@@ -0,0 +1,99 @@
+package user
+
+import (
+	"context"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/totp"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// throttleRepository keeps login throttles in memory and knows a single user.
+type throttleRepository struct {
+	Repository
+	user      *domain.User
+	throttles map[string]*domain.LoginThrottle
+}
+
+func (r *throttleRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	if email != r.user.Email {
+		return nil, nil
+	}
+	return r.user, nil
+}
+
+func (r *throttleRepository) GetUserByID(ctx context.Context, id int64) (*domain.User, error) {
+	if id != r.user.ID {
+		return nil, nil
+	}
+	return r.user, nil
+}
+
+func (r *throttleRepository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	return false, nil
+}
+
+func (r *throttleRepository) GetLoginThrottle(ctx context.Context, key string) (*domain.LoginThrottle, error) {
+	return r.throttles[key], nil
+}
+
+func (r *throttleRepository) CountLoginFailure(ctx context.Context, key string, now, resetBefore time.Time) (*domain.LoginThrottle, error) {
+	throttle := r.throttles[key]
+	if throttle == nil || throttle.LastFailedAt.Before(resetBefore) {
+		throttle = &domain.LoginThrottle{Key: key}
+		r.throttles[key] = throttle
+	}
+	throttle.FailedAttempts++
+	throttle.LastFailedAt = now
+	return throttle, nil
+}
+
+func (r *throttleRepository) DeleteLoginThrottle(ctx context.Context, key string) error {
+	delete(r.throttles, key)
+	return nil
+}
+
+func TestPasswordDoesNotResetWrongCodeCount(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enabledAt := time.Now()
+	repo := &throttleRepository{
+		user: &domain.User{
+			ID:            1,
+			Email:         "staff@example.com",
+			PasswordHash:  string(hash),
+			Roles:         []*domain.Role{{Name: domain.RoleStaff}},
+			TOTPSecret:    secret,
+			TOTPEnabledAt: &enabledAt,
+		},
+		throttles: map[string]*domain.LoginThrottle{},
+	}
+	s := &service{
+		userRepo:  repo,
+		jwtSecret: []byte("test secret"),
+		lockout:   LockoutPolicy{AccountMaxAttempts: 5, IPMaxAttempts: 20, ResetAfter: time.Hour},
+	}
+	ctx := context.Background()
+	key := accountThrottleKey(repo.user.Email)
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		result, err := s.LoginStaff(ctx, repo.user.Email, "correct horse", "")
+		if err != nil {
+			t.Fatalf("attempt %d: LoginStaff: %v", attempt, err)
+		}
+		if _, _, err := s.CompleteStaffLogin(ctx, result.ChallengeToken, "not-a-code", ""); err == nil || err.Error() != invalidCodeMessage {
+			t.Fatalf("attempt %d: CompleteStaffLogin error = %v, want %q", attempt, err, invalidCodeMessage)
+		}
+		if got := repo.throttles[key]; got == nil || got.FailedAttempts != attempt {
+			t.Fatalf("attempt %d: account throttle = %+v, want %d failed attempts", attempt, got, attempt)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/securetoken"
+	"mobigo-backend/pkg/totp"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	totpIssuer         = "MobiGo"
+	totpSkew           = 1 // Accept codes one step either side of now, for clock drift
+	recoveryCodeCount  = 10
+	challengeTokenTTL  = 5 * time.Minute
+	challengePurpose   = "two_factor_challenge"
+	invalidCodeMessage = "invalid two-factor code"
+)
+
+// StaffLoginResult is the outcome of the password step of a staff login. Either
+// Tokens is set, or the user has two-factor authentication and must finish the login
+// with ChallengeToken and a code.
+type StaffLoginResult struct {
+	Tokens         *AuthTokens
+	ChallengeToken string
+	// EnrollmentRequired means an admin requires two-factor authentication for this user
+	// but they have not set it up yet; they must do so to finish logging in.
+	EnrollmentRequired bool
+}
+
+// TOTPSetup is what a user adds to their authenticator app.
+type TOTPSetup struct {
+	Secret string
+	URI    string // otpauth:// URI, usually shown as a QR code
+}
+
+// startStaffLogin decides whether a staff member who gave the right password can
+// have a session straight away or must pass the second factor first.
+func (s *service) startStaffLogin(ctx context.Context, user *domain.User) (*StaffLoginResult, error) {
+	if user.TOTPEnabledAt == nil && !user.TwoFactorRequired {
+		tokens, err := s.startSession(ctx, user, s.tokens.StaffSessionTTL)
+		if err != nil {
+			return nil, err
+		}
+		return &StaffLoginResult{Tokens: tokens}, nil
+	}
+
+	challenge, err := s.issueChallengeToken(user)
+	if err != nil {
+		return nil, err
+	}
+	return &StaffLoginResult{
+		ChallengeToken:     challenge,
+		EnrollmentRequired: user.TOTPEnabledAt == nil,
+	}, nil
+}
+
+// SetupTwoFactorForLogin starts enrollment for a user whose login is waiting on it.
+func (s *service) SetupTwoFactorForLogin(ctx context.Context, challengeToken string) (*TOTPSetup, error) {
+	userID, err := s.parseChallengeToken(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	return s.SetupTwoFactor(ctx, userID)
+}
+
+// CompleteStaffLogin finishes a login that returned a challenge token. code is a TOTP
+// code or, once enrolled, a recovery code. If the login was waiting on enrollment, the
+// code also confirms the new authenticator and the recovery codes are returned.
+func (s *service) CompleteStaffLogin(ctx context.Context, challengeToken, code, ipAddress string) (*AuthTokens, []string, error) {
+	userID, err := s.parseChallengeToken(challengeToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil || user.DeactivatedAt != nil {
+		return nil, nil, errors.New("invalid or expired challenge")
+	}
+	// Wrong codes count towards the same lockout as wrong passwords.
+	if err := s.checkLockout(ctx, accountThrottleKey(user.Email)); err != nil {
+		return nil, nil, err
+	}
+
+	var recoveryCodes []string
+	if user.TOTPEnabledAt == nil {
+		recoveryCodes, err = s.enableTwoFactor(ctx, user, code)
+		if err != nil {
+			if err.Error() == invalidCodeMessage {
+				if err := s.recordLoginFailure(ctx, user.Email, ipAddress, user); err != nil {
+					return nil, nil, err
+				}
+			}
+			return nil, nil, err
+		}
+	} else {
+		ok, err := s.verifySecondFactor(ctx, user, code)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			if err := s.recordLoginFailure(ctx, user.Email, ipAddress, user); err != nil {
+				return nil, nil, err
+			}
+			return nil, nil, errors.New(invalidCodeMessage)
+		}
+	}
+
+	tokens, err := s.startSession(ctx, user, s.tokens.StaffSessionTTL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, recoveryCodes, nil
+}
+
+// SetupTwoFactor generates a new secret for the user. It is not used for logins
+// until EnableTwoFactor confirms the user's authenticator produces matching codes.
+func (s *service) SetupTwoFactor(ctx context.Context, userID int64) (*TOTPSetup, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	user.TOTPSecret = secret
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return &TOTPSetup{Secret: secret, URI: totp.URI(totpIssuer, user.Email, secret)}, nil
+}
+
+// EnableTwoFactor confirms setup with a code from the authenticator and returns the
+// user's recovery codes. They are shown only this once.
+func (s *service) EnableTwoFactor(ctx context.Context, userID int64, code string) ([]string, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+	return s.enableTwoFactor(ctx, user, code)
+}
+
+// DisableTwoFactor turns two-factor authentication off after checking the password.
+// Users an admin requires it for cannot turn it off.
+func (s *service) DisableTwoFactor(ctx context.Context, userID int64, password string) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return errors.New("current password is incorrect")
+	}
+	if user.TwoFactorRequired {
+		return errors.New("two-factor authentication is required for this account")
+	}
+	if user.TOTPEnabledAt == nil {
+		return errors.New("two-factor authentication is not enabled")
+	}
+	return s.clearTwoFactor(ctx, user)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a TOTP code.
+func (s *service) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New("user not found")
+	}
+	if user.TOTPEnabledAt == nil {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+	ok, err := s.verifyTOTP(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New(invalidCodeMessage)
+	}
+	return s.newRecoveryCodes(ctx, user.ID)
+}
+
+func (s *service) enableTwoFactor(ctx context.Context, user *domain.User, code string) ([]string, error) {
+	if user.TOTPSecret == "" {
+		return nil, errors.New("two-factor setup has not been started")
+	}
+	ok, err := s.verifyTOTP(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New(invalidCodeMessage)
+	}
+
+	recoveryCodes, err := s.newRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	// Reload so the step claimed by verifyTOTP is not overwritten.
+	user, err = s.userRepo.GetUserByID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	user.TOTPEnabledAt = &now
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return recoveryCodes, nil
+}
+
+// clearTwoFactor removes the user's secret and recovery codes.
+func (s *service) clearTwoFactor(ctx context.Context, user *domain.User) error {
+	user.TOTPSecret = ""
+	user.TOTPEnabledAt = nil
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		return err
+	}
+	return s.userRepo.ReplaceRecoveryCodes(ctx, user.ID, nil)
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code.
+func (s *service) verifySecondFactor(ctx context.Context, user *domain.User, code string) (bool, error) {
+	ok, err := s.verifyTOTP(ctx, user, code)
+	if err != nil || ok {
+		return ok, err
+	}
+	return s.userRepo.UseRecoveryCode(ctx, user.ID, securetoken.Hash(normalizeRecoveryCode(code)))
+}
+
+// verifyTOTP checks a code against the user's secret and claims its time step, so
+// the same code cannot be used again.
+func (s *service) verifyTOTP(ctx context.Context, user *domain.User, code string) (bool, error) {
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew)
+	if !ok {
+		return false, nil
+	}
+	return s.userRepo.ClaimTOTPStep(ctx, user.ID, step)
+}
+
+// newRecoveryCodes generates and stores a fresh set of recovery codes, replacing any old ones.
+func (s *service) newRecoveryCodes(ctx context.Context, userID int64) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		encoded := hex.EncodeToString(raw)
+		code := encoded[:5] + "-" + encoded[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, securetoken.Hash(normalizeRecoveryCode(code)))
+	}
+	if err := s.userRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode lets users type a recovery code with or without the dash and in any case.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
+
+// issueChallengeToken signs a short-lived token that proves the password step passed.
+// It carries no session ID, so the auth middleware does not accept it as an access token.
+func (s *service) issueChallengeToken(user *domain.User) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": user.ID,
+		"purpose": challengePurpose,
+		"exp":     time.Now().Add(challengeTokenTTL).Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.jwtSecret)
+}
+
+func (s *service) parseChallengeToken(challengeToken string) (int64, error) {
+	invalid := errors.New("invalid or expired challenge")
+	token, err := jwt.Parse(challengeToken, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return s.jwtSecret, nil
+	})
+	if err != nil || !token.Valid {
+		return 0, invalid
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != challengePurpose {
+		return 0, invalid
+	}
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, invalid
+	}
+	return int64(userID), nil
+}
@@ -0,0 +1,174 @@
+package user
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type twoFactorChallengeResponse struct {
+	TwoFactorRequired  bool   `json:"two_factor_required"`
+	ChallengeToken     string `json:"challenge_token"`
+	EnrollmentRequired bool   `json:"enrollment_required,omitempty"`
+}
+
+type completeStaffLoginRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"` // TOTP code or recovery code
+}
+
+type completeStaffLoginResponse struct {
+	loginResponse
+	RecoveryCodes []string `json:"recovery_codes,omitempty"` // Only when the login also completed enrollment
+}
+
+type challengeRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+}
+
+type twoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type disableTwoFactorRequest struct {
+	Password string `json:"password"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// --- Login Step Two ---
+
+func (h *Handler) completeStaffLoginHandler(w http.ResponseWriter, r *http.Request) {
+	var req completeStaffLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.ChallengeToken == "" || req.Code == "" {
+		http.Error(w, "Challenge token and code are required", http.StatusBadRequest)
+		return
+	}
+
+	tokens, recoveryCodes, err := h.service.CompleteStaffLogin(r.Context(), req.ChallengeToken, req.Code, clientIP(r))
+	if err != nil {
+		writeTwoFactorError(w, err, "Login failed")
+		return
+	}
+	json.NewEncoder(w).Encode(completeStaffLoginResponse{
+		loginResponse: newLoginResponse(tokens),
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+// setupTwoFactorForLoginHandler lets a user who must enroll before logging in get their secret.
+func (h *Handler) setupTwoFactorForLoginHandler(w http.ResponseWriter, r *http.Request) {
+	var req challengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ChallengeToken == "" {
+		http.Error(w, "Invalid request body, challenge token is required", http.StatusBadRequest)
+		return
+	}
+
+	setup, err := h.service.SetupTwoFactorForLogin(r.Context(), req.ChallengeToken)
+	if err != nil {
+		writeTwoFactorError(w, err, "Failed to set up two-factor authentication")
+		return
+	}
+	json.NewEncoder(w).Encode(twoFactorSetupResponse{Secret: setup.Secret, OTPAuthURI: setup.URI})
+}
+
+// --- Self-Service ---
+
+func (h *Handler) setupTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	setup, err := h.service.SetupTwoFactor(r.Context(), userID)
+	if err != nil {
+		writeTwoFactorError(w, err, "Failed to set up two-factor authentication")
+		return
+	}
+	json.NewEncoder(w).Encode(twoFactorSetupResponse{Secret: setup.Secret, OTPAuthURI: setup.URI})
+}
+
+func (h *Handler) enableTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	var req twoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		http.Error(w, "Invalid request body, code is required", http.StatusBadRequest)
+		return
+	}
+
+	recoveryCodes, err := h.service.EnableTwoFactor(r.Context(), userID, req.Code)
+	if err != nil {
+		writeTwoFactorError(w, err, "Failed to enable two-factor authentication")
+		return
+	}
+	json.NewEncoder(w).Encode(recoveryCodesResponse{RecoveryCodes: recoveryCodes})
+}
+
+func (h *Handler) disableTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	var req disableTwoFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Password == "" {
+		http.Error(w, "Invalid request body, password is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DisableTwoFactor(r.Context(), userID, req.Password); err != nil {
+		writeTwoFactorError(w, err, "Failed to disable two-factor authentication")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) regenerateRecoveryCodesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+	var req twoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		http.Error(w, "Invalid request body, code is required", http.StatusBadRequest)
+		return
+	}
+
+	recoveryCodes, err := h.service.RegenerateRecoveryCodes(r.Context(), userID, req.Code)
+	if err != nil {
+		writeTwoFactorError(w, err, "Failed to regenerate recovery codes")
+		return
+	}
+	json.NewEncoder(w).Encode(recoveryCodesResponse{RecoveryCodes: recoveryCodes})
+}
+
+func writeTwoFactorError(w http.ResponseWriter, err error, fallback string) {
+	switch err.Error() {
+	case "invalid or expired challenge", "invalid two-factor code":
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case "current password is incorrect", "account is deactivated",
+		"two-factor authentication is required for this account":
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case "two-factor authentication is already enabled",
+		"two-factor authentication is not enabled",
+		"two-factor setup has not been started":
+		http.Error(w, err.Error(), http.StatusConflict)
+	case "too many failed login attempts, try again later":
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	case "user not found":
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
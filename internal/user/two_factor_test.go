@@ -0,0 +1,65 @@
+package user
+
+import (
+	"context"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/totp"
+	"testing"
+	"time"
+)
+
+// stepRepository claims TOTP steps the way the database does: a step is accepted
+// only if it is later than the last one accepted for the user.
+type stepRepository struct {
+	Repository
+	lastStep map[int64]int64
+}
+
+func (r *stepRepository) ClaimTOTPStep(ctx context.Context, userID, step int64) (bool, error) {
+	if r.lastStep[userID] >= step {
+		return false, nil
+	}
+	r.lastStep[userID] = step
+	return true, nil
+}
+
+func TestVerifyTOTPRejectsReplayedCode(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &stepRepository{lastStep: map[int64]int64{}}
+	s := &service{userRepo: repo}
+	user := &domain.User{ID: 1, TOTPSecret: secret}
+	ctx := context.Background()
+
+	now := totp.Step(time.Now())
+	current, _ := totp.CodeAt(secret, now)
+	previous, _ := totp.CodeAt(secret, now-1)
+
+	tests := []struct {
+		name string
+		code string
+		want bool
+	}{
+		{"fresh code", current, true},
+		{"same code again", current, false},
+		{"older code after a newer one", previous, false},
+		{"wrong code", "000000", false},
+	}
+	for _, tt := range tests {
+		got, err := s.verifyTOTP(ctx, user, tt.code)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: verifyTOTP = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Another user's claims are tracked separately.
+	other := &domain.User{ID: 2, TOTPSecret: secret}
+	if ok, _ := s.verifyTOTP(ctx, other, current); !ok {
+		t.Error("code was rejected for a user who had not used it")
+	}
+}
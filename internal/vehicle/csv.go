@@ -0,0 +1,262 @@
+package vehicle
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mobigo-backend/internal/domain"
+	"strconv"
+	"strings"
+)
+
+// csvColumns is the column order of exports. Imports accept the columns in any order;
+// only vin, make, model, year and price are required.
+var csvColumns = []string{
+	"vin", "make", "model", "year", "price", "status", "description",
+	"mileage", "engine_capacity", "transmission", "fuel_type", "body_type", "color",
+	"seats", "plate_region", "condition", "service_history", "features", "branch_id",
+}
+
+var requiredColumns = []string{"vin", "make", "model", "year", "price"}
+
+// featureSeparator separates feature tags within the features cell.
+const featureSeparator = ";"
+
+// MaxImportRows caps the size of one import, since it runs in a single transaction.
+const MaxImportRows = 5000
+
+// ErrInvalidCSV is wrapped when the file itself cannot be imported, as opposed to
+// individual rows being rejected.
+var ErrInvalidCSV = errors.New("invalid CSV file")
+
+// ImportVehicles reads vehicles from CSV and upserts them by VIN. Every row is
+// validated first; the import is only committed if no row is rejected and dryRun is
+// false. A row replaces the vehicle's fields, except that an empty status or an
+// absent features column keeps the current value. Price changes are recorded with
+// "CSV import" as the reason. branch_id only places new vehicles; existing ones are
+// moved with a transfer.
+func (s *service) ImportVehicles(ctx context.Context, r io.Reader, dryRun bool, changedByID int64) (*ImportSummary, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not read the header row", ErrInvalidCSV)
+	}
+	columns, err := parseHeader(header)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &ImportSummary{DryRun: dryRun, Errors: []RowError{}}
+	var rows []*ImportRow
+	seenVINs := make(map[string]int)
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidCSV, line, err)
+		}
+		summary.Total++
+		if summary.Total > MaxImportRows {
+			return nil, fmt.Errorf("%w: at most %d rows can be imported at once", ErrInvalidCSV, MaxImportRows)
+		}
+
+		vehicle, err := parseRow(columns, record)
+		if err == nil {
+			if first, dup := seenVINs[vehicle.VIN]; dup {
+				err = fmt.Errorf("duplicate VIN, first seen on line %d", first)
+			} else {
+				seenVINs[vehicle.VIN] = line
+			}
+		}
+		if err != nil {
+			summary.Errors = append(summary.Errors, RowError{Line: line, VIN: strings.ToUpper(cell(columns, record, "vin")), Error: err.Error()})
+			continue
+		}
+		rows = append(rows, &ImportRow{Line: line, Vehicle: vehicle})
+	}
+
+	if err := s.repo.ImportVehicles(ctx, rows, changedByID, summary); err != nil {
+		return nil, err
+	}
+	summary.Rejected = len(summary.Errors)
+
+	if summary.Applied {
+		for _, id := range summary.ReleasedIDs {
+			if err := s.availability.VehicleReleased(ctx, id); err != nil {
+				log.Printf("VEHICLE ERROR: Failed to notify waitlist for vehicle %d: %v", id, err)
+			}
+		}
+		for _, c := range summary.PriceDrops {
+			if err := s.prices.PriceDropped(ctx, c.VehicleID, c.OldPrice, c.NewPrice); err != nil {
+				log.Printf("VEHICLE ERROR: Failed to announce price drop for vehicle %d: %v", c.VehicleID, err)
+			}
+		}
+	}
+	return summary, nil
+}
+
+// ExportVehicles writes every vehicle matching the filter as CSV, in the format
+// ImportVehicles reads. Paging fields in the filter are ignored.
+func (s *service) ExportVehicles(ctx context.Context, w io.Writer, filter SearchFilter) error {
+	filter, err := s.scopeToBranch(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvColumns); err != nil {
+		return err
+	}
+
+	filter.PageSize = maxPageSize
+	for filter.Page = 1; ; filter.Page++ {
+		vehicles, total, err := s.repo.SearchVehicles(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for _, v := range vehicles {
+			if err := writer.Write(formatRow(v)); err != nil {
+				return err
+			}
+		}
+		if int64(filter.Page*filter.PageSize) >= total || len(vehicles) == 0 {
+			break
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// parseHeader maps column names to their index and checks for unknown and missing columns.
+func parseHeader(header []string) (map[string]int, error) {
+	known := make(map[string]bool, len(csvColumns))
+	for _, c := range csvColumns {
+		known[c] = true
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !known[name] {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidCSV, name)
+		}
+		if _, dup := columns[name]; dup {
+			return nil, fmt.Errorf("%w: column %q appears twice", ErrInvalidCSV, name)
+		}
+		columns[name] = i
+	}
+	for _, name := range requiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: missing required column %q", ErrInvalidCSV, name)
+		}
+	}
+	return columns, nil
+}
+
+// cell returns the trimmed value of a column, or "" if the file has no such column.
+func cell(columns map[string]int, record []string, name string) string {
+	i, ok := columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// parseRow turns a record into a vehicle, applying the same checks as CreateVehicle.
+func parseRow(columns map[string]int, record []string) (*domain.Vehicle, error) {
+	get := func(name string) string { return cell(columns, record, name) }
+
+	v := &domain.Vehicle{
+		Make:        get("make"),
+		Model:       get("model"),
+		Description: get("description"),
+		Status:      domain.VehicleStatus(get("status")),
+	}
+	if v.Make == "" || v.Model == "" {
+		return nil, errors.New("make and model are required")
+	}
+
+	var err error
+	if v.Year, err = strconv.Atoi(get("year")); err != nil {
+		return nil, errors.New("year must be a whole number")
+	}
+	if v.Price, err = strconv.ParseFloat(get("price"), 64); err != nil || v.Price <= 0 {
+		return nil, errors.New("price must be a positive number")
+	}
+	switch v.Status {
+	case "", domain.VehicleStatusAvailable, domain.VehicleStatusBooked, domain.VehicleStatusSold, domain.VehicleStatusOnInstallment:
+	default:
+		return nil, fmt.Errorf("unknown status %q", v.Status)
+	}
+
+	specs := domain.VehicleSpecs{
+		Transmission:   domain.Transmission(get("transmission")),
+		FuelType:       domain.FuelType(get("fuel_type")),
+		BodyType:       domain.BodyType(get("body_type")),
+		Color:          get("color"),
+		PlateRegion:    get("plate_region"),
+		Condition:      domain.VehicleCondition(get("condition")),
+		ServiceHistory: domain.ServiceHistory(get("service_history")),
+	}
+	ints := map[string]*int{"mileage": &specs.Mileage, "engine_capacity": &specs.EngineCapacity, "seats": &specs.Seats}
+	for name, dst := range ints {
+		if raw := get(name); raw != "" {
+			if *dst, err = strconv.Atoi(raw); err != nil {
+				return nil, fmt.Errorf("%s must be a whole number", name)
+			}
+		}
+	}
+	v.VehicleSpecs = normalizeSpecs(specs)
+	if err := validateSpecs(v.VehicleSpecs); err != nil {
+		return nil, err
+	}
+
+	if _, ok := columns["features"]; ok {
+		names, err := normalizeFeatures(strings.Split(get("features"), featureSeparator))
+		if err != nil {
+			return nil, err
+		}
+		v.Features = featureRows(0, names)
+	}
+
+	if raw := get("branch_id"); raw != "" {
+		branchID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || branchID <= 0 {
+			return nil, errors.New("branch_id must be a branch ID")
+		}
+		v.BranchID = &branchID
+	}
+
+	if v.VIN, err = checkVIN(get("vin"), v.Make, v.Year); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// formatRow renders a vehicle in csvColumns order.
+func formatRow(v *domain.Vehicle) []string {
+	features := make([]string, 0, len(v.Features))
+	for _, f := range v.Features {
+		features = append(features, f.Name)
+	}
+	branchID := ""
+	if v.BranchID != nil {
+		branchID = strconv.FormatInt(*v.BranchID, 10)
+	}
+	return []string{
+		v.VIN, v.Make, v.Model, strconv.Itoa(v.Year), strconv.FormatFloat(v.Price, 'f', 2, 64),
+		string(v.Status), v.Description,
+		strconv.Itoa(v.Mileage), strconv.Itoa(v.EngineCapacity), string(v.Transmission),
+		string(v.FuelType), string(v.BodyType), v.Color, strconv.Itoa(v.Seats), v.PlateRegion,
+		string(v.Condition), string(v.ServiceHistory), strings.Join(features, featureSeparator), branchID,
+	}
+}
@@ -0,0 +1,78 @@
+package vehicle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mobigo-backend/pkg/middleware"
+	"net/http"
+	"time"
+)
+
+// maxImportSize caps the uploaded CSV file.
+const maxImportSize = 5 << 20
+
+// importVehiclesHandler upserts vehicles from a CSV file uploaded in the "file" form
+// field. With ?dry_run=true nothing is written, but the summary is still returned.
+func (h *Handler) importVehiclesHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize+1<<20) // Room for the form fields
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
+		http.Error(w, "Could not parse multipart form", http.StatusBadRequest)
+		return
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Invalid CSV file", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	summary, err := h.service.ImportVehicles(r.Context(), file, dryRun, userID)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCSV) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to import vehicles", http.StatusInternalServerError)
+		return
+	}
+
+	// Rejected rows mean nothing was written, which the client must fix and retry.
+	status := http.StatusOK
+	if summary.Rejected > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(summary)
+}
+
+// exportVehiclesHandler downloads the vehicles matching the listing filters as CSV.
+func (h *Handler) exportVehiclesHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseSearchFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	filter.ViewerID = userID
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="vehicles-%s.csv"`, time.Now().Format("20060102")))
+	if err := h.service.ExportVehicles(r.Context(), w, filter); err != nil {
+		// The header has been sent by now, so the client sees a truncated file.
+		log.Printf("VEHICLE ERROR: Failed to export vehicles: %v", err)
+	}
+}
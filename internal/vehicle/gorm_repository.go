@@ -2,8 +2,13 @@ package vehicle
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/likepattern"
+	"strings"
 )
 
 // gormRepository is the GORM implementation of the vehicle.Repository interface.
@@ -21,19 +26,135 @@ func (r *gormRepository) CreateVehicle(ctx context.Context, vehicle *domain.Vehi
 	return r.db.WithContext(ctx).Create(vehicle).Error
 }
 
-// GetAllVehicles retrieves all vehicle records from the database.
-// Note: In a real app, you would add pagination here.
-func (r *gormRepository) GetAllVehicles(ctx context.Context) ([]*domain.Vehicle, error) {
+// sortColumns maps the sort keys accepted by SearchVehicles to columns. Only these
+// can be sorted on, so user input never reaches the ORDER BY clause directly.
+var sortColumns = map[string]string{
+	"price":      "price",
+	"year":       "year",
+	"created_at": "created_at",
+	"make":       "make",
+	"mileage":    "mileage",
+}
+
+// SearchVehicles retrieves one page of the catalog, filtered and sorted.
+func (r *gormRepository) SearchVehicles(ctx context.Context, filter SearchFilter) ([]*domain.Vehicle, int64, error) {
+	query := r.db.WithContext(ctx).Model(&domain.Vehicle{})
+
+	if filter.Make != "" {
+		query = query.Where("LOWER(make) = LOWER(?)", filter.Make)
+	}
+	if filter.Model != "" {
+		query = query.Where("LOWER(model) = LOWER(?)", filter.Model)
+	}
+	if filter.YearMin != 0 {
+		query = query.Where("year >= ?", filter.YearMin)
+	}
+	if filter.YearMax != 0 {
+		query = query.Where("year <= ?", filter.YearMax)
+	}
+	if filter.PriceMin != 0 {
+		query = query.Where("price >= ?", filter.PriceMin)
+	}
+	if filter.PriceMax != 0 {
+		query = query.Where("price <= ?", filter.PriceMax)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.BranchID != 0 {
+		if filter.IncludeUnassigned {
+			query = query.Where("(branch_id = ? OR branch_id IS NULL)", filter.BranchID)
+		} else {
+			query = query.Where("branch_id = ?", filter.BranchID)
+		}
+	}
+	if filter.Transmission != "" {
+		query = query.Where("transmission = ?", filter.Transmission)
+	}
+	if filter.FuelType != "" {
+		query = query.Where("fuel_type = ?", filter.FuelType)
+	}
+	if filter.BodyType != "" {
+		query = query.Where("body_type = ?", filter.BodyType)
+	}
+	if filter.Condition != "" {
+		query = query.Where("vehicle_condition = ?", filter.Condition)
+	}
+	if filter.ServiceHistory != "" {
+		query = query.Where("service_history = ?", filter.ServiceHistory)
+	}
+	if filter.Color != "" {
+		query = query.Where("LOWER(color) = LOWER(?)", filter.Color)
+	}
+	if filter.PlateRegion != "" {
+		query = query.Where("UPPER(plate_region) = UPPER(?)", filter.PlateRegion)
+	}
+	if filter.SeatsMin != 0 {
+		query = query.Where("seats >= ?", filter.SeatsMin)
+	}
+	if filter.MileageMax != 0 {
+		query = query.Where("mileage <= ?", filter.MileageMax)
+	}
+	if filter.EngineMin != 0 {
+		query = query.Where("engine_capacity >= ?", filter.EngineMin)
+	}
+	if filter.EngineMax != 0 {
+		query = query.Where("engine_capacity <= ?", filter.EngineMax)
+	}
+	if len(filter.Features) > 0 {
+		// Only vehicles carrying every requested tag.
+		query = query.Where("id IN (?)", r.db.Model(&domain.VehicleFeature{}).
+			Select("vehicle_id").
+			Where("name IN ?", filter.Features).
+			Group("vehicle_id").
+			Having("COUNT(DISTINCT name) = ?", len(filter.Features)))
+	}
+	for _, word := range strings.Fields(filter.Query) {
+		like := likepattern.Contains(word)
+		query = query.Where(`(description ILIKE ? ESCAPE '\' OR make ILIKE ? ESCAPE '\' OR model ILIKE ? ESCAPE '\')`, like, like, like)
+	}
+
+	// A new session lets the same filtered query be used for both the count and the page.
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.PrimaryImageOnly {
+		query = query.Preload("Images", "is_primary = ?", true)
+	}
+
 	var vehicles []*domain.Vehicle
-	err := r.db.WithContext(ctx).Find(&vehicles).Error
-	return vehicles, err
+	err := query.Preload("Features").Preload("Branch").Order(orderClause(filter.Sort)).
+		Order("id desc"). // Tie-breaker so pages do not overlap
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&vehicles).Error
+	return vehicles, total, err
+}
+
+// orderClause turns a sort key such as "-price" into "price desc". Unknown keys
+// fall back to newest first.
+func orderClause(sort string) string {
+	direction := "asc"
+	if strings.HasPrefix(sort, "-") {
+		direction = "desc"
+		sort = strings.TrimPrefix(sort, "-")
+	}
+	column, ok := sortColumns[sort]
+	if !ok {
+		return "created_at desc"
+	}
+	return column + " " + direction
 }
 
 // GetVehicleByID retrieves a single vehicle by its ID.
 func (r *gormRepository) GetVehicleByID(ctx context.Context, id int64) (*domain.Vehicle, error) {
 	var vehicle domain.Vehicle
 	// THE CHANGE: Preload("Images") tells GORM to also fetch the vehicle's images.
-	err := r.db.WithContext(ctx).Preload("Images").First(&vehicle, id).Error
+	err := r.db.WithContext(ctx).Preload("Images").Preload("Features").Preload("Branch").First(&vehicle, id).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -43,9 +164,117 @@ func (r *gormRepository) GetVehicleByID(ctx context.Context, id int64) (*domain.
 	return &vehicle, nil
 }
 
+// GetVehiclesByIDs retrieves several vehicles at once, with their images, features and branch.
+func (r *gormRepository) GetVehiclesByIDs(ctx context.Context, ids []int64) ([]*domain.Vehicle, error) {
+	var vehicles []*domain.Vehicle
+	err := r.db.WithContext(ctx).Preload("Images").Preload("Features").Preload("Branch").Where("id IN ?", ids).Find(&vehicles).Error
+	return vehicles, err
+}
+
 // UpdateVehicle modifies an existing vehicle record in the database.
 func (r *gormRepository) UpdateVehicle(ctx context.Context, vehicle *domain.Vehicle) error {
-	return r.db.WithContext(ctx).Save(vehicle).Error
+	// Associations are omitted so a preloaded Features or Images slice is not re-saved.
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(vehicle).Error
+}
+
+// EditVehicle re-checks the status and deals under the vehicle's row lock, so a booking
+// confirmed or a payment settled since the service's checks is not overwritten.
+func (r *gormRepository) EditVehicle(ctx context.Context, vehicle *domain.Vehicle, fromStatus domain.VehicleStatus, change *domain.VehiclePriceChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current domain.Vehicle
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, vehicle.ID).Error; err != nil {
+			return err
+		}
+		if current.Status != fromStatus {
+			return fmt.Errorf("%w: the vehicle is now %s", ErrStatusTransition, current.Status)
+		}
+		if vehicle.Status != fromStatus {
+			deal, err := activeDeal(tx, vehicle.ID)
+			if err != nil {
+				return err
+			}
+			if deal != "" {
+				return fmt.Errorf("%w: it has %s", ErrVehicleInUse, deal)
+			}
+		}
+		if err := tx.Omit(clause.Associations).Save(vehicle).Error; err != nil {
+			return err
+		}
+		if change == nil {
+			return nil
+		}
+		return tx.Create(change).Error
+	})
+}
+
+// BranchExists checks for a branch by ID.
+func (r *gormRepository) BranchExists(ctx context.Context, id int64) (bool, error) {
+	return branchExists(r.db.WithContext(ctx), id)
+}
+
+// branchExists implements BranchExists on any connection, so imports can run it inside their transaction.
+func branchExists(db *gorm.DB, id int64) (bool, error) {
+	var count int64
+	err := db.Model(&domain.Branch{}).Where("id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+// TransferVehicle locks the vehicle, re-checks that it can move, moves it and records
+// where it came from.
+func (r *gormRepository) TransferVehicle(ctx context.Context, transfer *domain.VehicleTransfer) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var vehicle domain.Vehicle
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&vehicle, transfer.VehicleID).Error; err != nil {
+			return err
+		}
+		if vehicle.Status != domain.VehicleStatusAvailable {
+			return fmt.Errorf("%w: it is %s", ErrTransferUnavailable, vehicle.Status)
+		}
+		deal, err := activeDeal(tx, vehicle.ID)
+		if err != nil {
+			return err
+		}
+		if deal != "" {
+			return fmt.Errorf("%w: it has %s", ErrVehicleInUse, deal)
+		}
+		transfer.FromBranchID = vehicle.BranchID
+		if err := tx.Model(&vehicle).Update("branch_id", transfer.ToBranchID).Error; err != nil {
+			return err
+		}
+		return tx.Create(transfer).Error
+	})
+}
+
+// ListTransfers retrieves a vehicle's transfer history, newest first.
+func (r *gormRepository) ListTransfers(ctx context.Context, vehicleID int64) ([]*domain.VehicleTransfer, error) {
+	var transfers []*domain.VehicleTransfer
+	err := r.db.WithContext(ctx).
+		Preload("FromBranch").
+		Preload("ToBranch").
+		Where("vehicle_id = ?", vehicleID).
+		Order("created_at desc, id desc").
+		Find(&transfers).Error
+	return transfers, err
+}
+
+// ListPriceChanges retrieves a vehicle's price history, newest first.
+func (r *gormRepository) ListPriceChanges(ctx context.Context, vehicleID int64) ([]*domain.VehiclePriceChange, error) {
+	var changes []*domain.VehiclePriceChange
+	err := r.db.WithContext(ctx).Where("vehicle_id = ?", vehicleID).Order("created_at desc, id desc").Find(&changes).Error
+	return changes, err
+}
+
+// ReplaceFeatures deletes the vehicle's feature tags and inserts the new ones in one transaction.
+func (r *gormRepository) ReplaceFeatures(ctx context.Context, vehicleID int64, features []*domain.VehicleFeature) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("vehicle_id = ?", vehicleID).Delete(&domain.VehicleFeature{}).Error; err != nil {
+			return err
+		}
+		if len(features) == 0 {
+			return nil
+		}
+		return tx.Create(&features).Error
+	})
 }
 
 // DeleteVehicle soft-deletes a vehicle record from the database.
@@ -54,3 +283,176 @@ func (r *gormRepository) UpdateVehicle(ctx context.Context, vehicle *domain.Vehi
 func (r *gormRepository) DeleteVehicle(ctx context.Context, id int64) error {
 	return r.db.WithContext(ctx).Delete(&domain.Vehicle{}, id).Error
 }
+
+// ActiveDeal checks, in order, for open bookings, agreements with pending payments
+// and installment plans with unpaid installments.
+func (r *gormRepository) ActiveDeal(ctx context.Context, vehicleID int64) (string, error) {
+	return activeDeal(r.db.WithContext(ctx), vehicleID)
+}
+
+// activeDeal implements ActiveDeal on any connection, so imports can run it inside their transaction.
+func activeDeal(db *gorm.DB, vehicleID int64) (string, error) {
+	var count int64
+	err := db.Model(&domain.Booking{}).
+		Where("vehicle_id = ? AND status IN ?", vehicleID, []domain.BookingStatus{
+			domain.BookingStatusPending, domain.BookingStatusConfirmed, domain.BookingStatusRescheduleRequested,
+		}).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return "an open booking", err
+	}
+
+	// Agreements and payments hang off the booking, not the vehicle.
+	agreementIDs := db.Model(&domain.Agreement{}).
+		Select("agreements.id").
+		Joins("JOIN bookings ON bookings.id = agreements.booking_id").
+		Where("bookings.vehicle_id = ?", vehicleID)
+
+	err = db.Model(&domain.Payment{}).
+		Where("agreement_id IN (?) AND status = ?", agreementIDs, domain.PaymentStatusPending).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return "an agreement awaiting payment", err
+	}
+
+	err = db.Model(&domain.Installment{}).
+		Joins("JOIN payments ON payments.id = installments.payment_id").
+		Where("payments.agreement_id IN (?) AND installments.status <> ?", agreementIDs, domain.InstallmentStatusPaid).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return "an installment plan with unpaid installments", err
+	}
+	return "", nil
+}
+
+// errRollback aborts an import transaction that must not be committed.
+var errRollback = errors.New("rollback import")
+
+// ImportVehicles creates or updates each row's vehicle, matching on VIN. Soft-deleted
+// vehicles still own their VIN, so rows for them are rejected rather than revived.
+func (r *gormRepository) ImportVehicles(ctx context.Context, rows []*ImportRow, changedByID int64, summary *ImportSummary) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		knownBranches := make(map[int64]bool)
+		for _, row := range rows {
+			in := row.Vehicle
+
+			if in.BranchID != nil {
+				known, checked := knownBranches[*in.BranchID]
+				if !checked {
+					var err error
+					if known, err = branchExists(tx, *in.BranchID); err != nil {
+						return err
+					}
+					knownBranches[*in.BranchID] = known
+				}
+				if !known {
+					summary.Errors = append(summary.Errors, RowError{Line: row.Line, VIN: in.VIN, Error: ErrUnknownBranch.Error()})
+					continue
+				}
+			}
+
+			var existing domain.Vehicle
+			err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Where("vin = ?", in.VIN).First(&existing).Error
+			switch {
+			case err == gorm.ErrRecordNotFound:
+				if in.Status == "" {
+					in.Status = domain.VehicleStatusAvailable
+				}
+				if err := checkManualTransition(domain.VehicleStatusAvailable, in.Status); err != nil {
+					summary.Errors = append(summary.Errors, RowError{Line: row.Line, VIN: in.VIN, Error: err.Error()})
+					continue
+				}
+				if err := tx.Create(in).Error; err != nil {
+					return err
+				}
+				summary.Created++
+				continue
+			case err != nil:
+				return err
+			case existing.DeletedAt.Valid:
+				summary.Errors = append(summary.Errors, RowError{Line: row.Line, VIN: in.VIN, Error: "VIN belongs to a deleted vehicle"})
+				continue
+			}
+
+			// Moving stock goes through transfers, so that it is recorded.
+			if in.BranchID != nil && (existing.BranchID == nil || *existing.BranchID != *in.BranchID) {
+				summary.Errors = append(summary.Errors, RowError{Line: row.Line, VIN: in.VIN, Error: "use a transfer to move a vehicle to another branch"})
+				continue
+			}
+
+			if in.Status != "" && in.Status != existing.Status {
+				if err := checkManualTransition(existing.Status, in.Status); err != nil {
+					summary.Errors = append(summary.Errors, RowError{Line: row.Line, VIN: in.VIN, Error: err.Error()})
+					continue
+				}
+				deal, err := activeDeal(tx, existing.ID)
+				if err != nil {
+					return err
+				}
+				if deal != "" {
+					summary.Errors = append(summary.Errors, RowError{Line: row.Line, VIN: in.VIN, Error: fmt.Sprintf("%v: it has %s", ErrVehicleInUse, deal)})
+					continue
+				}
+			}
+
+			if in.Price != existing.Price {
+				change := &domain.VehiclePriceChange{
+					VehicleID:   existing.ID,
+					OldPrice:    existing.Price,
+					NewPrice:    in.Price,
+					ChangedByID: changedByID,
+					Reason:      "CSV import",
+				}
+				if err := tx.Create(change).Error; err != nil {
+					return err
+				}
+				if change.NewPrice < change.OldPrice {
+					summary.PriceDrops = append(summary.PriceDrops, change)
+				}
+			}
+
+			wasAvailable := existing.Status == domain.VehicleStatusAvailable
+			existing.Make = in.Make
+			existing.Model = in.Model
+			existing.Year = in.Year
+			existing.Price = in.Price
+			existing.Description = in.Description
+			existing.VehicleSpecs = in.VehicleSpecs
+			if in.Status != "" {
+				existing.Status = in.Status
+			}
+			if err := tx.Omit(clause.Associations).Save(&existing).Error; err != nil {
+				return err
+			}
+			if in.Features != nil {
+				if err := tx.Where("vehicle_id = ?", existing.ID).Delete(&domain.VehicleFeature{}).Error; err != nil {
+					return err
+				}
+				for _, f := range in.Features {
+					f.VehicleID = existing.ID
+				}
+				if len(in.Features) > 0 {
+					if err := tx.Create(&in.Features).Error; err != nil {
+						return err
+					}
+				}
+			}
+			if !wasAvailable && existing.Status == domain.VehicleStatusAvailable {
+				summary.ReleasedIDs = append(summary.ReleasedIDs, existing.ID)
+			}
+			summary.Updated++
+		}
+
+		if summary.DryRun || len(summary.Errors) > 0 {
+			return errRollback
+		}
+		return nil
+	})
+	if err == errRollback {
+		return nil
+	}
+	if err == nil {
+		summary.Applied = true
+	}
+	return err
+}
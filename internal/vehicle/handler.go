@@ -2,9 +2,12 @@ package vehicle
 
 import (
 	"encoding/json"
+	"fmt"
 	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/middleware"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
@@ -20,15 +23,31 @@ func NewHandler(s Service) *Handler {
 }
 
 // RegisterRoutes sets up the routing for the vehicle feature.
-func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler) {
+func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler, authz *middleware.Authorizer) {
 	r := router.PathPrefix("/api/vehicles").Subrouter()
 	r.Use(authMiddleware) // Apply middleware to all routes in this subrouter
 
-	r.HandleFunc("", h.createVehicleHandler).Methods("POST")
+	staffOnly := authz.Require(domain.RoleStaff, domain.RoleAdmin)
+
+	// Any logged-in user may browse stock; only staff may change it.
 	r.HandleFunc("", h.getAllVehiclesHandler).Methods("GET")
+	// Registered before /{id} so "export", "import" and "compare" are not taken for IDs.
+	r.Handle("/export", staffOnly(http.HandlerFunc(h.exportVehiclesHandler))).Methods("GET")
+	r.Handle("/import", staffOnly(http.HandlerFunc(h.importVehiclesHandler))).Methods("POST")
+	r.HandleFunc("/compare", h.compareVehiclesHandler).Methods("GET")
 	r.HandleFunc("/{id}", h.getVehicleByIDHandler).Methods("GET")
-	r.HandleFunc("/{id}", h.updateVehicleHandler).Methods("PUT")
-	r.HandleFunc("/{id}", h.deleteVehicleHandler).Methods("DELETE")
+	r.Handle("/vin/{vin}", staffOnly(http.HandlerFunc(h.decodeVINHandler))).Methods("GET")
+	r.Handle("", staffOnly(http.HandlerFunc(h.createVehicleHandler))).Methods("POST")
+	r.Handle("/{id}/price-history", staffOnly(http.HandlerFunc(h.getPriceHistoryHandler))).Methods("GET")
+	r.Handle("/{id}/transfers", staffOnly(http.HandlerFunc(h.transferVehicleHandler))).Methods("POST")
+	r.Handle("/{id}/transfers", staffOnly(http.HandlerFunc(h.getTransferHistoryHandler))).Methods("GET")
+	r.Handle("/{id}", staffOnly(http.HandlerFunc(h.updateVehicleHandler))).Methods("PUT")
+	r.Handle("/{id}", staffOnly(http.HandlerFunc(h.deleteVehicleHandler))).Methods("DELETE")
+
+	// The showroom is public and read-only, so prospective buyers can browse without an account.
+	showroom := router.PathPrefix("/api/showroom").Subrouter()
+	showroom.HandleFunc("", h.listShowroomHandler).Methods("GET")
+	showroom.HandleFunc("/{id}", h.getShowroomVehicleHandler).Methods("GET")
 }
 
 // createVehicleRequest defines the expected JSON body for creating a vehicle.
@@ -40,6 +59,11 @@ type createVehicleRequest struct {
 	Price       float64 `json:"price"`
 	Description string  `json:"description"`
 	Status      string  `json:"status"`
+
+	// Spec fields are sent flat, alongside the ones above.
+	domain.VehicleSpecs
+	Features []string `json:"features"`
+	BranchID *int64   `json:"branch_id"`
 }
 
 // createVehicleHandler handles the creation of a new vehicle.
@@ -50,7 +74,7 @@ func (h *Handler) createVehicleHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Basic validation
+	// Basic validation; the VIN itself is checked by the service.
 	if req.VIN == "" || req.Make == "" || req.Model == "" {
 		http.Error(w, "VIN, Make, and Model are required", http.StatusBadRequest)
 		return
@@ -60,8 +84,16 @@ func (h *Handler) createVehicleHandler(w http.ResponseWriter, r *http.Request) {
 	// This is the handler's job: to translate raw input into safe, internal types.
 	vehicleStatus := domain.VehicleStatus(req.Status)
 
-	vehicle, err := h.service.CreateVehicle(r.Context(), req.Make, req.Model, req.VIN, req.Description, req.Year, req.Price, vehicleStatus)
+	vehicle, err := h.service.CreateVehicle(r.Context(), req.Make, req.Model, req.VIN, req.Description, req.Year, req.Price, vehicleStatus, req.VehicleSpecs, req.Features, req.BranchID)
 	if err != nil {
+		if isInputError(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if isConflictError(err) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "Failed to create vehicle", http.StatusInternalServerError)
 		return
 	}
@@ -71,9 +103,34 @@ func (h *Handler) createVehicleHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(vehicle)
 }
 
-// getAllVehiclesHandler handles retrieving all vehicles.
+// listVehiclesResponse is one page of the catalog.
+type listVehiclesResponse struct {
+	Data     []*domain.Vehicle `json:"data"`
+	Total    int64             `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+}
+
+// getAllVehiclesHandler searches the catalog. Query params: make, model, year_min, year_max,
+// price_min, price_max, status, q (searches description, make and model), transmission,
+// fuel_type, body_type, condition, service_history, color, plate_region, seats_min,
+// mileage_max, engine_min, engine_max, feature (repeatable or comma-separated; all must match),
+// sort (price, year, created_at, make or mileage; prefix with "-" for descending), page, page_size,
+// branch_id (a branch ID, or "all"; staff default to their own branch).
 func (h *Handler) getAllVehiclesHandler(w http.ResponseWriter, r *http.Request) {
-	vehicles, err := h.service.GetAllVehicles(r.Context())
+	filter, err := parseSearchFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	filter.ViewerID = userID
+
+	vehicles, total, err := h.service.SearchVehicles(r.Context(), filter)
 	if err != nil {
 		http.Error(w, "Failed to retrieve vehicles", http.StatusInternalServerError)
 		return
@@ -81,7 +138,74 @@ func (h *Handler) getAllVehiclesHandler(w http.ResponseWriter, r *http.Request)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(vehicles)
+	json.NewEncoder(w).Encode(listVehiclesResponse{Data: vehicles, Total: total, Page: filter.Page, PageSize: filter.PageSize})
+}
+
+// parseSearchFilter reads the catalog query parameters. Paging is normalized so the
+// response can echo the page actually served.
+func parseSearchFilter(r *http.Request) (SearchFilter, error) {
+	q := r.URL.Query()
+	filter := SearchFilter{
+		Make:   q.Get("make"),
+		Model:  q.Get("model"),
+		Status: domain.VehicleStatus(q.Get("status")),
+		Query:  q.Get("q"),
+		Sort:   q.Get("sort"),
+
+		Transmission:   domain.Transmission(q.Get("transmission")),
+		FuelType:       domain.FuelType(q.Get("fuel_type")),
+		BodyType:       domain.BodyType(q.Get("body_type")),
+		Condition:      domain.VehicleCondition(q.Get("condition")),
+		ServiceHistory: domain.ServiceHistory(q.Get("service_history")),
+		Color:          q.Get("color"),
+		PlateRegion:    q.Get("plate_region"),
+	}
+	switch branch := q.Get("branch_id"); branch {
+	case "":
+	case "all":
+		filter.AllBranches = true
+	default:
+		id, err := strconv.ParseInt(branch, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid branch_id")
+		}
+		filter.BranchID = id
+	}
+	for _, v := range q["feature"] {
+		filter.Features = append(filter.Features, strings.Split(v, ",")...)
+	}
+	features, err := normalizeFeatures(filter.Features)
+	if err != nil {
+		return filter, err
+	}
+	filter.Features = features
+
+	ints := map[string]*int{
+		"year_min": &filter.YearMin, "year_max": &filter.YearMax,
+		"seats_min": &filter.SeatsMin, "mileage_max": &filter.MileageMax,
+		"engine_min": &filter.EngineMin, "engine_max": &filter.EngineMax,
+		"page": &filter.Page, "page_size": &filter.PageSize,
+	}
+	for name, dst := range ints {
+		if v := q.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s", name)
+			}
+			*dst = n
+		}
+	}
+	floats := map[string]*float64{"price_min": &filter.PriceMin, "price_max": &filter.PriceMax}
+	for name, dst := range floats {
+		if v := q.Get(name); v != "" {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s", name)
+			}
+			*dst = n
+		}
+	}
+	return NormalizePaging(filter), nil
 }
 
 // getVehicleByIDHandler handles retrieving a single vehicle by its ID.
@@ -117,6 +241,11 @@ type updateVehicleRequest struct {
 	Price       float64 `json:"price"`
 	Description string  `json:"description"`
 	Status      string  `json:"status"`
+
+	// Spec fields are sent flat, alongside the ones above.
+	domain.VehicleSpecs
+	Features          []string `json:"features"`
+	PriceChangeReason string   `json:"price_change_reason"` // Required when the price changes
 }
 
 // updateVehicleHandler handles updating an existing vehicle.
@@ -134,11 +263,25 @@ func (h *Handler) updateVehicleHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+
 	// CHANGED: Convert the string to our enum type.
 	vehicleStatus := domain.VehicleStatus(req.Status)
 
-	updatedVehicle, err := h.service.UpdateVehicle(r.Context(), id, req.Make, req.Model, req.VIN, req.Description, req.Year, req.Price, vehicleStatus)
+	updatedVehicle, err := h.service.UpdateVehicle(r.Context(), id, req.Make, req.Model, req.VIN, req.Description, req.Year, req.Price, vehicleStatus, req.VehicleSpecs, req.Features, userID, req.PriceChangeReason)
 	if err != nil {
+		if isInputError(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if isConflictError(err) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "Failed to update vehicle", http.StatusInternalServerError)
 		return
 	}
@@ -163,9 +306,123 @@ func (h *Handler) deleteVehicleHandler(w http.ResponseWriter, r *http.Request) {
 
 	err = h.service.DeleteVehicle(r.Context(), id)
 	if err != nil {
+		if isConflictError(err) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "Failed to delete vehicle", http.StatusInternalServerError)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent) // 204 No Content is standard for successful deletes
 }
+
+// decodeVINHandler validates a VIN and returns its manufacturer, model years and plant code.
+func (h *Handler) decodeVINHandler(w http.ResponseWriter, r *http.Request) {
+	info, err := h.service.DecodeVIN(r.Context(), mux.Vars(r)["vin"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(info)
+}
+
+// getPriceHistoryHandler lists a vehicle's price changes, newest first.
+func (h *Handler) getPriceHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid vehicle ID", http.StatusBadRequest)
+		return
+	}
+
+	changes, err := h.service.GetPriceHistory(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve price history", http.StatusInternalServerError)
+		return
+	}
+	if changes == nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(changes)
+}
+
+// transferVehicleRequest defines the expected JSON body for moving a vehicle to another branch.
+type transferVehicleRequest struct {
+	BranchID int64  `json:"branch_id"`
+	Reason   string `json:"reason"`
+}
+
+// transferVehicleHandler moves a vehicle to another branch.
+func (h *Handler) transferVehicleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid vehicle ID", http.StatusBadRequest)
+		return
+	}
+	var req transferVehicleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.BranchID == 0 {
+		http.Error(w, "branch_id is required", http.StatusBadRequest)
+		return
+	}
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+
+	transfer, err := h.service.TransferVehicle(r.Context(), id, req.BranchID, userID, req.Reason)
+	if err != nil {
+		if isInputError(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if isConflictError(err) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to transfer vehicle", http.StatusInternalServerError)
+		return
+	}
+	if transfer == nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// getTransferHistoryHandler returns the branches a vehicle has been moved between, newest first.
+func (h *Handler) getTransferHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid vehicle ID", http.StatusBadRequest)
+		return
+	}
+
+	transfers, err := h.service.GetTransferHistory(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve transfer history", http.StatusInternalServerError)
+		return
+	}
+	if transfers == nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(transfers)
+}
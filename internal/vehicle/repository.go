@@ -5,11 +5,106 @@ import (
 	"mobigo-backend/internal/domain"
 )
 
+// SearchFilter narrows down and orders the vehicle catalog. Zero values are ignored.
+type SearchFilter struct {
+	Make     string // Exact match, case-insensitive
+	Model    string // Exact match, case-insensitive
+	YearMin  int
+	YearMax  int
+	PriceMin float64
+	PriceMax float64
+	Status   domain.VehicleStatus
+
+	Transmission   domain.Transmission
+	FuelType       domain.FuelType
+	BodyType       domain.BodyType
+	Condition      domain.VehicleCondition
+	ServiceHistory domain.ServiceHistory
+	Color          string // Case-insensitive
+	PlateRegion    string // Case-insensitive
+	SeatsMin       int
+	MileageMax     int
+	EngineMin      int
+	EngineMax      int
+	Features       []string // The vehicle must have every one of these feature tags
+
+	Query    string // Every word must appear in the description, make or model
+	Sort     string // One of the keys in sortColumns, optionally prefixed with "-" for descending
+	Page     int
+	PageSize int
+
+	PrimaryImageOnly bool // Preload only each vehicle's primary image; set by the showroom
+
+	BranchID int64 // Only vehicles kept at this branch
+	// IncludeUnassigned widens BranchID to vehicles not assigned to any branch yet.
+	IncludeUnassigned bool
+	// ViewerID is the staff member searching. When BranchID is 0 and AllBranches is
+	// false, the search defaults to the viewer's home branch and unassigned stock.
+	ViewerID    int64
+	AllBranches bool
+}
+
+// ImportRow is one validated CSV row, ready to be created or merged into the
+// vehicle with the same VIN. An empty Status keeps the current status (or means
+// available for a new vehicle), and nil Features keeps the current feature tags.
+type ImportRow struct {
+	Line    int
+	Vehicle *domain.Vehicle
+}
+
+// RowError explains why one CSV row was rejected.
+type RowError struct {
+	Line  int    `json:"line"`
+	VIN   string `json:"vin,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportSummary reports what an import did, or would have done for a dry run.
+type ImportSummary struct {
+	DryRun   bool       `json:"dry_run"`
+	Applied  bool       `json:"applied"` // False for dry runs and for imports with rejected rows
+	Total    int        `json:"total"`
+	Created  int        `json:"created"`
+	Updated  int        `json:"updated"`
+	Rejected int        `json:"rejected"`
+	Errors   []RowError `json:"errors"`
+
+	ReleasedIDs []int64                      `json:"-"` // Vehicles the import made available again
+	PriceDrops  []*domain.VehiclePriceChange `json:"-"` // Price changes that lowered the price
+}
+
 // Repository is the interface that provides vehicle storage methods.
 type Repository interface {
 	CreateVehicle(ctx context.Context, vehicle *domain.Vehicle) error
-	GetAllVehicles(ctx context.Context) ([]*domain.Vehicle, error)
+	// SearchVehicles returns one page of vehicles matching the filter, plus the total match count.
+	SearchVehicles(ctx context.Context, filter SearchFilter) ([]*domain.Vehicle, int64, error)
 	GetVehicleByID(ctx context.Context, id int64) (*domain.Vehicle, error)
+	// UpdateVehicle saves the vehicle's own columns; images and features are left alone.
 	UpdateVehicle(ctx context.Context, vehicle *domain.Vehicle) error
+	// GetVehiclesByIDs returns the vehicles that exist among ids, with images and features, in no particular order.
+	GetVehiclesByIDs(ctx context.Context, ids []int64) ([]*domain.Vehicle, error)
+	// EditVehicle saves a staff edit of the vehicle and its price change, if any, in one
+	// transaction. Under a lock on the vehicle it fails if the status is no longer
+	// fromStatus, and if the edit changes the status while an active deal holds it.
+	EditVehicle(ctx context.Context, vehicle *domain.Vehicle, fromStatus domain.VehicleStatus, change *domain.VehiclePriceChange) error
+	// ListPriceChanges returns the vehicle's price history, newest first.
+	ListPriceChanges(ctx context.Context, vehicleID int64) ([]*domain.VehiclePriceChange, error)
+	// BranchExists reports whether a branch with the given ID exists.
+	BranchExists(ctx context.Context, id int64) (bool, error)
+	// TransferVehicle moves the vehicle to transfer.ToBranchID and records the transfer,
+	// in one transaction. FromBranchID is filled in from the vehicle. Under a lock on the
+	// vehicle it fails if the vehicle is no longer available or an active deal holds it.
+	TransferVehicle(ctx context.Context, transfer *domain.VehicleTransfer) error
+	// ListTransfers returns the vehicle's transfers with their branches, newest first.
+	ListTransfers(ctx context.Context, vehicleID int64) ([]*domain.VehicleTransfer, error)
+	// ReplaceFeatures swaps the vehicle's feature tags for the given rows.
+	ReplaceFeatures(ctx context.Context, vehicleID int64, features []*domain.VehicleFeature) error
 	DeleteVehicle(ctx context.Context, id int64) error
+	// ActiveDeal describes the open booking, unpaid agreement or running installment plan
+	// holding the vehicle, or returns "" if there is none.
+	ActiveDeal(ctx context.Context, vehicleID int64) (string, error)
+	// ImportVehicles upserts the rows by VIN in one transaction and records the outcome in
+	// summary. Price changes are recorded under changedByID. The transaction is rolled
+	// back for a dry run or if any row is rejected.
+	ImportVehicles(ctx context.Context, rows []*ImportRow, changedByID int64, summary *ImportSummary) error
 }
@@ -2,65 +2,312 @@ package vehicle
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/vin"
+	"strings"
 	"time"
 )
 
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+
+	// MaxCompareVehicles is how many vehicles can be compared side by side.
+	MaxCompareVehicles = 4
+)
+
+// AvailabilityListener is told when a vehicle becomes available again,
+// so that customers waiting for it can be offered the vehicle.
+type AvailabilityListener interface {
+	VehicleReleased(ctx context.Context, vehicleID int64) error
+}
+
+// AvailabilityListeners passes a release on to each listener in turn. A failing
+// listener does not stop the others; their errors are returned together.
+type AvailabilityListeners []AvailabilityListener
+
+func (ls AvailabilityListeners) VehicleReleased(ctx context.Context, vehicleID int64) error {
+	var errs []error
+	for _, l := range ls {
+		if err := l.VehicleReleased(ctx, vehicleID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PriceListener is told when a vehicle's list price is lowered, so that customers
+// who saved the vehicle can be alerted.
+type PriceListener interface {
+	PriceDropped(ctx context.Context, vehicleID int64, oldPrice, newPrice float64) error
+}
+
+// HomeBranches looks up the branch a staff member works at. It returns 0 for
+// users without a home branch.
+type HomeBranches interface {
+	HomeBranchID(ctx context.Context, userID int64) (int64, error)
+}
+
+// DiscountApplier sets the effective price of vehicles from the running discount campaigns.
+type DiscountApplier interface {
+	ApplyDiscounts(ctx context.Context, vehicles []*domain.Vehicle) error
+}
+
+// ErrPriceReasonRequired is returned when a price change comes without a reason.
+var ErrPriceReasonRequired = errors.New("a reason is required when changing the price")
+
+var (
+	// ErrUnknownBranch is returned when a vehicle is assigned to a branch that does not exist.
+	ErrUnknownBranch = errors.New("branch not found")
+	// ErrSameBranch is returned when a vehicle is transferred to the branch it is already at.
+	ErrSameBranch = errors.New("vehicle is already at this branch")
+	// ErrTransferUnavailable is wrapped when a vehicle that is booked or sold is transferred.
+	ErrTransferUnavailable = errors.New("only available vehicles can be transferred")
+)
+
+// ErrCompareCount is wrapped when a comparison asks for too few or too many vehicles.
+var ErrCompareCount = errors.New("invalid number of vehicles to compare")
+
 // Service defines the business logic operations for vehicles.
 type Service interface {
-	CreateVehicle(ctx context.Context, make, model, vin, description string, year int, price float64, status domain.VehicleStatus) (*domain.Vehicle, error)
-	GetAllVehicles(ctx context.Context) ([]*domain.Vehicle, error)
+	// CreateVehicle adds a vehicle to the stock of branchID, or to no branch if it is nil.
+	CreateVehicle(ctx context.Context, make, model, vin, description string, year int, price float64, status domain.VehicleStatus, specs domain.VehicleSpecs, features []string, branchID *int64) (*domain.Vehicle, error)
+	// SearchVehicles returns one page of the catalog and the total number of matches.
+	SearchVehicles(ctx context.Context, filter SearchFilter) ([]*domain.Vehicle, int64, error)
 	GetVehicleByID(ctx context.Context, id int64) (*domain.Vehicle, error)
-	UpdateVehicle(ctx context.Context, id int64, make, model, vin, description string, year int, price float64, status domain.VehicleStatus) (*domain.Vehicle, error)
+	// ImportVehicles upserts vehicles from CSV by VIN; see the implementation for the rules.
+	ImportVehicles(ctx context.Context, r io.Reader, dryRun bool, changedByID int64) (*ImportSummary, error)
+	// ExportVehicles writes the vehicles matching the filter as CSV.
+	ExportVehicles(ctx context.Context, w io.Writer, filter SearchFilter) error
+	// DecodeVIN validates a VIN and returns what can be read from it.
+	DecodeVIN(ctx context.Context, rawVIN string) (*vin.Info, error)
+	// SearchShowroom is SearchVehicles restricted to available stock, for the public catalog.
+	SearchShowroom(ctx context.Context, filter SearchFilter) ([]*domain.Vehicle, int64, error)
+	// GetShowroomVehicle returns the vehicle only if it is available, otherwise nil.
+	GetShowroomVehicle(ctx context.Context, id int64) (*domain.Vehicle, error)
+	// UpdateVehicle replaces the vehicle's fields and specs. A nil features slice keeps the
+	// current feature tags; any other value replaces them. A price change is recorded in
+	// the price history under changedByID and needs a priceReason.
+	UpdateVehicle(ctx context.Context, id int64, make, model, vin, description string, year int, price float64, status domain.VehicleStatus, specs domain.VehicleSpecs, features []string, changedByID int64, priceReason string) (*domain.Vehicle, error)
+	// GetPriceHistory lists the vehicle's price changes, newest first. It returns nil if the vehicle does not exist.
+	GetPriceHistory(ctx context.Context, id int64) ([]*domain.VehiclePriceChange, error)
+	// TransferVehicle moves an available vehicle to another branch and records the move.
+	// It returns nil if the vehicle does not exist.
+	TransferVehicle(ctx context.Context, id, toBranchID, transferredByID int64, reason string) (*domain.VehicleTransfer, error)
+	// GetTransferHistory lists the vehicle's transfers, newest first. It returns nil if the vehicle does not exist.
+	GetTransferHistory(ctx context.Context, id int64) ([]*domain.VehicleTransfer, error)
+	// CompareVehicles returns two to MaxCompareVehicles vehicles in the order asked for.
+	// It returns nil if any of them does not exist.
+	CompareVehicles(ctx context.Context, ids []int64) ([]*domain.Vehicle, error)
 	DeleteVehicle(ctx context.Context, id int64) error
 }
 
 // service is the implementation of the Service interface.
 type service struct {
 	repo           Repository
+	availability   AvailabilityListener
+	prices         PriceListener
+	discounts      DiscountApplier
+	branches       HomeBranches
 	contextTimeout time.Duration
 }
 
 // NewService creates a new instance of the vehicle service.
-func NewService(repo Repository, timeout time.Duration) Service {
+func NewService(repo Repository, availability AvailabilityListener, prices PriceListener, discounts DiscountApplier, branches HomeBranches, timeout time.Duration) Service {
 	return &service{
 		repo:           repo,
+		availability:   availability,
+		prices:         prices,
+		discounts:      discounts,
+		branches:       branches,
 		contextTimeout: timeout,
 	}
 }
 
 // CreateVehicle handles the business logic for creating a new vehicle.
-func (s *service) CreateVehicle(ctx context.Context, make, model, vin, description string, year int, price float64, status domain.VehicleStatus) (*domain.Vehicle, error) {
+func (s *service) CreateVehicle(ctx context.Context, make, model, vin, description string, year int, price float64, status domain.VehicleStatus, specs domain.VehicleSpecs, features []string, branchID *int64) (*domain.Vehicle, error) {
+	// New stock always starts out available; the other statuses come from bookings and payments.
+	if status == "" {
+		status = domain.VehicleStatusAvailable
+	}
+	if err := checkManualTransition(domain.VehicleStatusAvailable, status); err != nil {
+		return nil, err
+	}
+	specs = normalizeSpecs(specs)
+	if err := validateSpecs(specs); err != nil {
+		return nil, err
+	}
+	features, err := normalizeFeatures(features)
+	if err != nil {
+		return nil, err
+	}
+	vin, err = checkVIN(vin, make, year)
+	if err != nil {
+		return nil, err
+	}
+	if branchID != nil {
+		if err := s.checkBranch(ctx, *branchID); err != nil {
+			return nil, err
+		}
+	}
+
 	newVehicle := &domain.Vehicle{
-		Make:        make,
-		Model:       model,
-		Year:        year,
-		VIN:         vin,
-		Price:       price,
-		Description: description,
-		Status:      status,
+		Make:         make,
+		Model:        model,
+		Year:         year,
+		VIN:          vin,
+		Price:        price,
+		Description:  description,
+		Status:       status,
+		VehicleSpecs: specs,
+		Features:     featureRows(0, features), // GORM fills in the vehicle ID on create
+		BranchID:     branchID,
 	}
 
-	err := s.repo.CreateVehicle(ctx, newVehicle)
+	err = s.repo.CreateVehicle(ctx, newVehicle)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.discounts.ApplyDiscounts(ctx, []*domain.Vehicle{newVehicle}); err != nil {
+		return nil, err
+	}
 
 	return newVehicle, nil
 }
 
-// GetAllVehicles retrieves all vehicles.
-func (s *service) GetAllVehicles(ctx context.Context) ([]*domain.Vehicle, error) {
-	return s.repo.GetAllVehicles(ctx)
+// SearchVehicles retrieves a filtered, sorted page of vehicles.
+func (s *service) SearchVehicles(ctx context.Context, filter SearchFilter) ([]*domain.Vehicle, int64, error) {
+	filter, err := s.scopeToBranch(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.search(ctx, NormalizePaging(filter))
+}
+
+// scopeToBranch limits a staff search to the viewer's home branch, plus stock not
+// assigned to a branch yet, unless a branch or all branches were asked for explicitly.
+func (s *service) scopeToBranch(ctx context.Context, filter SearchFilter) (SearchFilter, error) {
+	if filter.BranchID != 0 || filter.AllBranches || filter.ViewerID == 0 {
+		return filter, nil
+	}
+	branchID, err := s.branches.HomeBranchID(ctx, filter.ViewerID)
+	if err != nil {
+		return filter, err
+	}
+	filter.BranchID = branchID
+	filter.IncludeUnassigned = true
+	return filter, nil
+}
+
+// checkBranch returns ErrUnknownBranch if the branch does not exist.
+func (s *service) checkBranch(ctx context.Context, branchID int64) error {
+	exists, err := s.repo.BranchExists(ctx, branchID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrUnknownBranch
+	}
+	return nil
+}
+
+// search runs a repository search and fills in effective prices.
+func (s *service) search(ctx context.Context, filter SearchFilter) ([]*domain.Vehicle, int64, error) {
+	vehicles, total, err := s.repo.SearchVehicles(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := s.discounts.ApplyDiscounts(ctx, vehicles); err != nil {
+		return nil, 0, err
+	}
+	return vehicles, total, nil
 }
 
-// GetVehicleByID retrieves a single vehicle by its ID.
+// DecodeVIN decodes a VIN, so staff can check it and prefill make and year before creating a vehicle.
+func (s *service) DecodeVIN(ctx context.Context, rawVIN string) (*vin.Info, error) {
+	return vin.Decode(rawVIN)
+}
+
+// SearchShowroom searches the public catalog. Only available vehicles are listed,
+// whatever status the caller asked for.
+func (s *service) SearchShowroom(ctx context.Context, filter SearchFilter) ([]*domain.Vehicle, int64, error) {
+	filter.Status = domain.VehicleStatusAvailable
+	filter.PrimaryImageOnly = true
+	return s.search(ctx, NormalizePaging(filter))
+}
+
+// GetShowroomVehicle retrieves a vehicle for the public catalog. Booked, sold and
+// financed vehicles are treated as not found.
+func (s *service) GetShowroomVehicle(ctx context.Context, id int64) (*domain.Vehicle, error) {
+	vehicle, err := s.GetVehicleByID(ctx, id)
+	if err != nil || vehicle == nil {
+		return nil, err
+	}
+	if vehicle.Status != domain.VehicleStatusAvailable {
+		return nil, nil
+	}
+	return vehicle, nil
+}
+
+// NormalizePaging fills in a default page and page size and caps the page size.
+func NormalizePaging(filter SearchFilter) SearchFilter {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultPageSize
+	}
+	if filter.PageSize > maxPageSize {
+		filter.PageSize = maxPageSize
+	}
+	return filter
+}
+
+// GetVehicleByID retrieves a single vehicle by its ID, with its effective price.
 func (s *service) GetVehicleByID(ctx context.Context, id int64) (*domain.Vehicle, error) {
-	return s.repo.GetVehicleByID(ctx, id)
+	vehicle, err := s.repo.GetVehicleByID(ctx, id)
+	if err != nil || vehicle == nil {
+		return nil, err
+	}
+	if err := s.discounts.ApplyDiscounts(ctx, []*domain.Vehicle{vehicle}); err != nil {
+		return nil, err
+	}
+	return vehicle, nil
+}
+
+// GetPriceHistory retrieves the price changes of a vehicle.
+func (s *service) GetPriceHistory(ctx context.Context, id int64) ([]*domain.VehiclePriceChange, error) {
+	vehicle, err := s.repo.GetVehicleByID(ctx, id)
+	if err != nil || vehicle == nil {
+		return nil, err
+	}
+	changes, err := s.repo.ListPriceChanges(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if changes == nil {
+		changes = []*domain.VehiclePriceChange{} // nil means the vehicle was not found
+	}
+	return changes, nil
 }
 
 // UpdateVehicle handles the business logic for updating an existing vehicle.
-func (s *service) UpdateVehicle(ctx context.Context, id int64, make, model, vin, description string, year int, price float64, status domain.VehicleStatus) (*domain.Vehicle, error) {
+func (s *service) UpdateVehicle(ctx context.Context, id int64, make, model, vin, description string, year int, price float64, status domain.VehicleStatus, specs domain.VehicleSpecs, features []string, changedByID int64, priceReason string) (*domain.Vehicle, error) {
+	specs = normalizeSpecs(specs)
+	if err := validateSpecs(specs); err != nil {
+		return nil, err
+	}
+	if features != nil {
+		var err error
+		if features, err = normalizeFeatures(features); err != nil {
+			return nil, err
+		}
+	}
+
 	// First, get the existing vehicle to make sure it exists.
 	vehicleToUpdate, err := s.repo.GetVehicleByID(ctx, id)
 	if err != nil {
@@ -70,6 +317,45 @@ func (s *service) UpdateVehicle(ctx context.Context, id int64, make, model, vin,
 		return nil, nil // Or a custom "not found" error
 	}
 
+	// Stock entered before VINs were validated can still be edited as long as the
+	// VIN, make and year are left alone.
+	if vin != vehicleToUpdate.VIN || make != vehicleToUpdate.Make || year != vehicleToUpdate.Year {
+		if vin, err = checkVIN(vin, make, year); err != nil {
+			return nil, err
+		}
+	}
+
+	// An empty status keeps the current one, so clients need not echo it back.
+	if status == "" {
+		status = vehicleToUpdate.Status
+	}
+	if status != vehicleToUpdate.Status {
+		if err := checkManualTransition(vehicleToUpdate.Status, status); err != nil {
+			return nil, err
+		}
+		if err := s.checkNoActiveDeal(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	var priceChange *domain.VehiclePriceChange
+	if price != vehicleToUpdate.Price {
+		priceReason = strings.TrimSpace(priceReason)
+		if priceReason == "" {
+			return nil, ErrPriceReasonRequired
+		}
+		priceChange = &domain.VehiclePriceChange{
+			VehicleID:   vehicleToUpdate.ID,
+			OldPrice:    vehicleToUpdate.Price,
+			NewPrice:    price,
+			ChangedByID: changedByID,
+			Reason:      priceReason,
+		}
+	}
+
+	fromStatus := vehicleToUpdate.Status
+	wasAvailable := fromStatus == domain.VehicleStatusAvailable
+
 	// Update the fields
 	vehicleToUpdate.Make = make
 	vehicleToUpdate.Model = model
@@ -78,19 +364,137 @@ func (s *service) UpdateVehicle(ctx context.Context, id int64, make, model, vin,
 	vehicleToUpdate.Price = price
 	vehicleToUpdate.Description = description
 	vehicleToUpdate.Status = status
+	vehicleToUpdate.VehicleSpecs = specs
 
-	// Save the updated vehicle back to the database
-	err = s.repo.UpdateVehicle(ctx, vehicleToUpdate)
-	if err != nil {
+	// Save the updated vehicle back to the database, together with its price change if any
+	if err := s.repo.EditVehicle(ctx, vehicleToUpdate, fromStatus, priceChange); err != nil {
+		return nil, err
+	}
+	if features != nil {
+		rows := featureRows(vehicleToUpdate.ID, features)
+		if err := s.repo.ReplaceFeatures(ctx, vehicleToUpdate.ID, rows); err != nil {
+			return nil, err
+		}
+		vehicleToUpdate.Features = rows
+	}
+	if err := s.discounts.ApplyDiscounts(ctx, []*domain.Vehicle{vehicleToUpdate}); err != nil {
 		return nil, err
 	}
 
+	if !wasAvailable && vehicleToUpdate.Status == domain.VehicleStatusAvailable {
+		if err := s.availability.VehicleReleased(ctx, vehicleToUpdate.ID); err != nil {
+			log.Printf("VEHICLE ERROR: Failed to notify waitlist for vehicle %d: %v", vehicleToUpdate.ID, err)
+		}
+	}
+	if priceChange != nil && priceChange.NewPrice < priceChange.OldPrice {
+		if err := s.prices.PriceDropped(ctx, priceChange.VehicleID, priceChange.OldPrice, priceChange.NewPrice); err != nil {
+			log.Printf("VEHICLE ERROR: Failed to announce price drop for vehicle %d: %v", priceChange.VehicleID, err)
+		}
+	}
+
 	return vehicleToUpdate, nil
 }
 
-// DeleteVehicle handles the business logic for deleting a vehicle.
+// TransferVehicle moves a vehicle between branches. Booked and sold vehicles stay
+// where they are, since their viewing or handover is arranged at that branch.
+func (s *service) TransferVehicle(ctx context.Context, id, toBranchID, transferredByID int64, reason string) (*domain.VehicleTransfer, error) {
+	vehicle, err := s.repo.GetVehicleByID(ctx, id)
+	if err != nil || vehicle == nil {
+		return nil, err
+	}
+	if err := s.checkBranch(ctx, toBranchID); err != nil {
+		return nil, err
+	}
+	if vehicle.BranchID != nil && *vehicle.BranchID == toBranchID {
+		return nil, ErrSameBranch
+	}
+	if vehicle.Status != domain.VehicleStatusAvailable {
+		return nil, fmt.Errorf("%w: it is %s", ErrTransferUnavailable, vehicle.Status)
+	}
+	if err := s.checkNoActiveDeal(ctx, id); err != nil {
+		return nil, err
+	}
+
+	transfer := &domain.VehicleTransfer{
+		VehicleID:       id,
+		ToBranchID:      toBranchID,
+		TransferredByID: transferredByID,
+		Reason:          strings.TrimSpace(reason),
+	}
+	if err := s.repo.TransferVehicle(ctx, transfer); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// GetTransferHistory retrieves the branch transfers of a vehicle.
+func (s *service) GetTransferHistory(ctx context.Context, id int64) ([]*domain.VehicleTransfer, error) {
+	vehicle, err := s.repo.GetVehicleByID(ctx, id)
+	if err != nil || vehicle == nil {
+		return nil, err
+	}
+	transfers, err := s.repo.ListTransfers(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if transfers == nil {
+		transfers = []*domain.VehicleTransfer{} // nil means the vehicle was not found
+	}
+	return transfers, nil
+}
+
+// CompareVehicles retrieves the vehicles to compare, with their effective prices.
+func (s *service) CompareVehicles(ctx context.Context, ids []int64) ([]*domain.Vehicle, error) {
+	seen := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return nil, fmt.Errorf("%w: vehicle %d is listed twice", ErrCompareCount, id)
+		}
+		seen[id] = true
+	}
+	if len(ids) < 2 || len(ids) > MaxCompareVehicles {
+		return nil, fmt.Errorf("%w: between 2 and %d vehicles can be compared", ErrCompareCount, MaxCompareVehicles)
+	}
+
+	found, err := s.repo.GetVehiclesByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]*domain.Vehicle, len(found))
+	for _, v := range found {
+		byID[v.ID] = v
+	}
+	vehicles := make([]*domain.Vehicle, 0, len(ids))
+	for _, id := range ids {
+		v, ok := byID[id]
+		if !ok {
+			return nil, nil
+		}
+		vehicles = append(vehicles, v)
+	}
+	if err := s.discounts.ApplyDiscounts(ctx, vehicles); err != nil {
+		return nil, err
+	}
+	return vehicles, nil
+}
+
+// DeleteVehicle handles the business logic for deleting a vehicle. Vehicles tied
+// to an active deal cannot be deleted.
 func (s *service) DeleteVehicle(ctx context.Context, id int64) error {
-	// We could add business logic here, e.g., check if the vehicle is in an active booking.
-	// For now, we just pass the call to the repository.
+	if err := s.checkNoActiveDeal(ctx, id); err != nil {
+		return err
+	}
 	return s.repo.DeleteVehicle(ctx, id)
 }
+
+// checkNoActiveDeal returns an ErrVehicleInUse error naming what holds the vehicle, if anything.
+func (s *service) checkNoActiveDeal(ctx context.Context, id int64) error {
+	deal, err := s.repo.ActiveDeal(ctx, id)
+	if err != nil {
+		return err
+	}
+	if deal != "" {
+		return fmt.Errorf("%w: it has %s", ErrVehicleInUse, deal)
+	}
+	return nil
+}
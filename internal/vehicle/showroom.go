@@ -0,0 +1,168 @@
+package vehicle
+
+import (
+	"encoding/json"
+	"errors"
+	"mobigo-backend/internal/domain"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ShowroomVehicle is the public view of a vehicle. It includes the specs but leaves
+// out internal fields such as the VIN and status, which are not meant for anonymous visitors.
+type ShowroomVehicle struct {
+	ID    int64   `json:"id"`
+	Make  string  `json:"make"`
+	Model string  `json:"model"`
+	Year  int     `json:"year"`
+	Price float64 `json:"price"`
+	// EffectivePrice is the price after any running discount campaign.
+	EffectivePrice float64   `json:"effective_price"`
+	Description    string    `json:"description"`
+	PrimaryImage   string    `json:"primary_image,omitempty"`
+	Images         []string  `json:"images,omitempty"`
+	ListedAt       time.Time `json:"listed_at"`
+	// Branch is where the vehicle can be viewed.
+	Branch *domain.Branch `json:"branch,omitempty"`
+
+	domain.VehicleSpecs
+	Features []string `json:"features,omitempty"`
+}
+
+// newShowroomVehicle builds the public view. The primary image falls back to the
+// first image when none is flagged; the full gallery is only included when requested.
+func newShowroomVehicle(v *domain.Vehicle, withGallery bool) ShowroomVehicle {
+	sv := ShowroomVehicle{
+		ID:             v.ID,
+		Make:           v.Make,
+		Model:          v.Model,
+		Year:           v.Year,
+		Price:          v.Price,
+		EffectivePrice: v.EffectivePrice,
+		Description:    v.Description,
+		ListedAt:       v.CreatedAt,
+		Branch:         v.Branch,
+
+		VehicleSpecs: v.VehicleSpecs,
+	}
+	for _, f := range v.Features {
+		sv.Features = append(sv.Features, f.Name)
+	}
+	for _, img := range v.Images {
+		if img.IsPrimary || sv.PrimaryImage == "" {
+			sv.PrimaryImage = img.ImageURL
+		}
+		if withGallery {
+			sv.Images = append(sv.Images, img.ImageURL)
+		}
+	}
+	return sv
+}
+
+// showroomListResponse is one page of the public catalog.
+type showroomListResponse struct {
+	Data     []ShowroomVehicle `json:"data"`
+	Total    int64             `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+}
+
+// listShowroomHandler lists available vehicles. It accepts the same query params as
+// the staff listing, except that status is always "available"; branch_id narrows the
+// list to one branch.
+func (h *Handler) listShowroomHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseSearchFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	vehicles, total, err := h.service.SearchShowroom(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "Failed to retrieve vehicles", http.StatusInternalServerError)
+		return
+	}
+
+	resp := showroomListResponse{Data: make([]ShowroomVehicle, 0, len(vehicles)), Total: total, Page: filter.Page, PageSize: filter.PageSize}
+	for _, v := range vehicles {
+		resp.Data = append(resp.Data, newShowroomVehicle(v, false))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// getShowroomVehicleHandler returns a single available vehicle with its full gallery.
+func (h *Handler) getShowroomVehicleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid vehicle ID", http.StatusBadRequest)
+		return
+	}
+
+	vehicle, err := h.service.GetShowroomVehicle(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve vehicle", http.StatusInternalServerError)
+		return
+	}
+	if vehicle == nil {
+		http.Error(w, "Vehicle not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newShowroomVehicle(vehicle, true))
+}
+
+// comparedVehicle is one column of a comparison. Unlike the showroom, a comparison
+// may include vehicles that are no longer available, so their status is shown.
+type comparedVehicle struct {
+	ShowroomVehicle
+	Status domain.VehicleStatus `json:"status"`
+}
+
+// compareVehiclesHandler returns the vehicles given as ?ids=1,2,3 side by side, in that order.
+func (h *Handler) compareVehiclesHandler(w http.ResponseWriter, r *http.Request) {
+	var ids []int64
+	for _, raw := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid vehicle ID in ids", http.StatusBadRequest)
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	vehicles, err := h.service.CompareVehicles(r.Context(), ids)
+	if err != nil {
+		if errors.Is(err, ErrCompareCount) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to compare vehicles", http.StatusInternalServerError)
+		return
+	}
+	if vehicles == nil {
+		http.Error(w, "One or more vehicles not found", http.StatusNotFound)
+		return
+	}
+
+	resp := make([]comparedVehicle, 0, len(vehicles))
+	for _, v := range vehicles {
+		resp = append(resp, comparedVehicle{ShowroomVehicle: newShowroomVehicle(v, false), Status: v.Status})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,90 @@
+package vehicle
+
+import (
+	"errors"
+	"fmt"
+	"mobigo-backend/internal/domain"
+	"strings"
+)
+
+// ErrInvalidSpec is wrapped by every specification validation error, so handlers
+// can tell bad input apart from storage failures.
+var ErrInvalidSpec = errors.New("invalid vehicle specification")
+
+// maxFeatures caps the number of feature tags on one vehicle.
+const maxFeatures = 50
+
+// validateSpecs checks the enum fields and numeric ranges of a specification.
+// Empty enum fields are allowed, since older stock was entered without specs.
+func validateSpecs(specs domain.VehicleSpecs) error {
+	switch specs.Transmission {
+	case "", domain.TransmissionManual, domain.TransmissionAutomatic, domain.TransmissionCVT:
+	default:
+		return fmt.Errorf("%w: unknown transmission %q", ErrInvalidSpec, specs.Transmission)
+	}
+	switch specs.FuelType {
+	case "", domain.FuelTypePetrol, domain.FuelTypeDiesel, domain.FuelTypeHybrid, domain.FuelTypeElectric:
+	default:
+		return fmt.Errorf("%w: unknown fuel type %q", ErrInvalidSpec, specs.FuelType)
+	}
+	switch specs.BodyType {
+	case "", domain.BodyTypeSedan, domain.BodyTypeHatchback, domain.BodyTypeSUV, domain.BodyTypeMPV,
+		domain.BodyTypePickup, domain.BodyTypeCoupe, domain.BodyTypeVan:
+	default:
+		return fmt.Errorf("%w: unknown body type %q", ErrInvalidSpec, specs.BodyType)
+	}
+	switch specs.Condition {
+	case domain.VehicleConditionNew, domain.VehicleConditionUsed:
+	default:
+		return fmt.Errorf("%w: condition must be new or used", ErrInvalidSpec)
+	}
+	switch specs.ServiceHistory {
+	case "", domain.ServiceHistoryFull, domain.ServiceHistoryPartial, domain.ServiceHistoryNone:
+	default:
+		return fmt.Errorf("%w: unknown service history %q", ErrInvalidSpec, specs.ServiceHistory)
+	}
+	if specs.Mileage < 0 || specs.EngineCapacity < 0 || specs.Seats < 0 {
+		return fmt.Errorf("%w: mileage, engine capacity and seats cannot be negative", ErrInvalidSpec)
+	}
+	return nil
+}
+
+// normalizeSpecs fills in defaults and tidies free-text fields before validation.
+func normalizeSpecs(specs domain.VehicleSpecs) domain.VehicleSpecs {
+	if specs.Condition == "" {
+		specs.Condition = domain.VehicleConditionUsed
+	}
+	specs.Color = strings.TrimSpace(specs.Color)
+	specs.PlateRegion = strings.ToUpper(strings.TrimSpace(specs.PlateRegion))
+	return specs
+}
+
+// normalizeFeatures lower-cases and trims feature names and drops blanks and duplicates.
+func normalizeFeatures(names []string) ([]string, error) {
+	seen := make(map[string]bool, len(names))
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		if len(name) > 50 {
+			return nil, fmt.Errorf("%w: feature %q is longer than 50 characters", ErrInvalidSpec, name)
+		}
+		seen[name] = true
+		out = append(out, name)
+	}
+	if len(out) > maxFeatures {
+		return nil, fmt.Errorf("%w: at most %d features are allowed", ErrInvalidSpec, maxFeatures)
+	}
+	return out, nil
+}
+
+// featureRows turns feature names into rows for the given vehicle.
+func featureRows(vehicleID int64, names []string) []*domain.VehicleFeature {
+	rows := make([]*domain.VehicleFeature, 0, len(names))
+	for _, name := range names {
+		rows = append(rows, &domain.VehicleFeature{VehicleID: vehicleID, Name: name})
+	}
+	return rows
+}
@@ -0,0 +1,53 @@
+package vehicle
+
+import (
+	"errors"
+	"fmt"
+	"mobigo-backend/internal/domain"
+)
+
+var (
+	// ErrInvalidStatus is wrapped when a status is not one of the VehicleStatus values.
+	ErrInvalidStatus = errors.New("invalid vehicle status")
+	// ErrStatusTransition is wrapped when staff try to set a status that only the
+	// booking and payment flows may set.
+	ErrStatusTransition = errors.New("status change not allowed")
+	// ErrVehicleInUse is wrapped when a vehicle cannot be released or deleted because
+	// it is tied to an open booking, an unpaid agreement or a running installment plan.
+	ErrVehicleInUse = errors.New("vehicle is tied to an active deal")
+)
+
+// validStatus reports whether s is a known vehicle status.
+func validStatus(s domain.VehicleStatus) bool {
+	switch s {
+	case domain.VehicleStatusAvailable, domain.VehicleStatusBooked, domain.VehicleStatusSold, domain.VehicleStatusOnInstallment:
+		return true
+	}
+	return false
+}
+
+// checkManualTransition enforces the status changes staff may make by hand. Booked,
+// sold and on-installment are only ever set by confirming a booking or settling a
+// payment, so the one manual change is releasing a booked vehicle back to available;
+// the caller must still make sure no active deal holds it. Sold and on-installment
+// are final: there is no flow for reversing a sale or an installment plan.
+func checkManualTransition(from, to domain.VehicleStatus) error {
+	if !validStatus(to) {
+		return fmt.Errorf("%w %q", ErrInvalidStatus, to)
+	}
+	if to == from {
+		return nil
+	}
+	if from == domain.VehicleStatusSold || from == domain.VehicleStatusOnInstallment {
+		return fmt.Errorf("%w: a vehicle that is %s keeps its status", ErrStatusTransition, from)
+	}
+	if to == domain.VehicleStatusAvailable {
+		return nil
+	}
+	return fmt.Errorf("%w: %q is set by the booking and payment flows", ErrStatusTransition, to)
+}
+
+// isConflictError reports whether err was caused by the vehicle's deal state.
+func isConflictError(err error) bool {
+	return errors.Is(err, ErrStatusTransition) || errors.Is(err, ErrVehicleInUse) || errors.Is(err, ErrTransferUnavailable)
+}
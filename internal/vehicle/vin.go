@@ -0,0 +1,35 @@
+package vehicle
+
+import (
+	"errors"
+	"fmt"
+	"mobigo-backend/pkg/vin"
+)
+
+// ErrVINMismatch is wrapped when a VIN decodes to a different make or model year
+// than the one submitted with it.
+var ErrVINMismatch = errors.New("VIN does not match the vehicle")
+
+// checkVIN normalizes and validates a VIN and cross-checks it against the submitted
+// make and year. It returns the normalized VIN.
+func checkVIN(rawVIN, make string, year int) (string, error) {
+	info, err := vin.Decode(rawVIN)
+	if err != nil {
+		return "", err
+	}
+	if !info.CheckMake(make) {
+		return "", fmt.Errorf("%w: it was issued to %s, not %s", ErrVINMismatch, info.Manufacturer.Make, make)
+	}
+	if !info.CheckYear(year) {
+		return "", fmt.Errorf("%w: its model year code allows %v, not %d", ErrVINMismatch, info.ModelYears, year)
+	}
+	return info.VIN, nil
+}
+
+// isInputError reports whether err was caused by invalid vehicle data rather than
+// a storage failure.
+func isInputError(err error) bool {
+	return errors.Is(err, ErrInvalidSpec) || errors.Is(err, ErrVINMismatch) || errors.Is(err, ErrPriceReasonRequired) ||
+		errors.Is(err, ErrInvalidStatus) || errors.Is(err, vin.ErrInvalidFormat) || errors.Is(err, vin.ErrCheckDigit) ||
+		errors.Is(err, ErrUnknownBranch) || errors.Is(err, ErrSameBranch)
+}
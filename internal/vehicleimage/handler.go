@@ -3,6 +3,8 @@ package vehicleimage
 import (
 	"fmt"
 	"io"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/middleware"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -20,15 +22,18 @@ func NewHandler(s Service) *Handler {
 	return &Handler{service: s}
 }
 
-func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler) {
+func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler, authz *middleware.Authorizer) {
+	// Managing vehicle photos is a staff task.
+	staffOnly := authz.Require(domain.RoleStaff, domain.RoleAdmin)
+
 	// Note: We are attaching the image upload route to the /vehicles path for logical grouping.
 	r := router.PathPrefix("/api/vehicles/{vehicleID}/images").Subrouter()
-	r.Use(authMiddleware)
+	r.Use(authMiddleware, staffOnly)
 	r.HandleFunc("", h.uploadImageHandler).Methods("POST")
 
 	// Routes for a specific image (by its own ID)
 	imageRouter := router.PathPrefix("/api/images/{id}").Subrouter()
-	imageRouter.Use(authMiddleware)
+	imageRouter.Use(authMiddleware, staffOnly)
 	imageRouter.HandleFunc("", h.deleteImageHandler).Methods("DELETE")
 	imageRouter.HandleFunc("/primary", h.setPrimaryImageHandler).Methods("PUT")
 }
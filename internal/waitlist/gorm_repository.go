@@ -0,0 +1,135 @@
+package waitlist
+
+import (
+	"context"
+	"mobigo-backend/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// activeStatuses are the statuses in which an entry still occupies a place in the queue.
+var activeStatuses = []domain.WaitlistStatus{domain.WaitlistStatusWaiting, domain.WaitlistStatusHolding}
+
+type gormRepository struct {
+	db *gorm.DB
+}
+
+func NewGORMRepository(db *gorm.DB) Repository {
+	return &gormRepository{db: db}
+}
+
+func (r *gormRepository) Create(ctx context.Context, entry *domain.WaitlistEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *gormRepository) Update(ctx context.Context, entry *domain.WaitlistEntry) error {
+	return r.db.WithContext(ctx).Save(entry).Error
+}
+
+func (r *gormRepository) GetActiveEntry(ctx context.Context, userID, vehicleID int64) (*domain.WaitlistEntry, error) {
+	return r.first(r.db.WithContext(ctx).
+		Where("user_id = ? AND vehicle_id = ? AND status IN ?", userID, vehicleID, activeStatuses))
+}
+
+func (r *gormRepository) GetActiveHold(ctx context.Context, vehicleID int64, now time.Time) (*domain.WaitlistEntry, error) {
+	return r.first(r.db.WithContext(ctx).
+		Where("vehicle_id = ? AND status = ? AND hold_expires_at > ?", vehicleID, domain.WaitlistStatusHolding, now))
+}
+
+func (r *gormRepository) GetNextWaiting(ctx context.Context, vehicleID int64) (*domain.WaitlistEntry, error) {
+	return r.first(r.db.WithContext(ctx).
+		Where("vehicle_id = ? AND status = ?", vehicleID, domain.WaitlistStatusWaiting).
+		Order("created_at asc, id asc"))
+}
+
+func (r *gormRepository) PromoteNext(ctx context.Context, vehicleID int64, now, expiresAt time.Time) (*domain.WaitlistEntry, error) {
+	var promoted *domain.WaitlistEntry
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var vehicle domain.Vehicle
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&vehicle, vehicleID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil
+			}
+			return err
+		}
+		if vehicle.Status != domain.VehicleStatusAvailable {
+			return nil
+		}
+
+		var holds int64
+		if err := tx.Model(&domain.WaitlistEntry{}).
+			Where("vehicle_id = ? AND status = ? AND hold_expires_at > ?", vehicleID, domain.WaitlistStatusHolding, now).
+			Count(&holds).Error; err != nil || holds > 0 {
+			return err
+		}
+
+		next, err := r.first(tx.Where("vehicle_id = ? AND status = ?", vehicleID, domain.WaitlistStatusWaiting).
+			Order("created_at asc, id asc"))
+		if err != nil || next == nil {
+			return err
+		}
+		// The vehicle lock already serializes promotions; the status condition also keeps
+		// the entry from being promoted if the customer left the queue meanwhile.
+		result := tx.Model(&domain.WaitlistEntry{}).
+			Where("id = ? AND status = ?", next.ID, domain.WaitlistStatusWaiting).
+			Updates(map[string]interface{}{"status": domain.WaitlistStatusHolding, "hold_expires_at": expiresAt})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		next.Status = domain.WaitlistStatusHolding
+		next.HoldExpiresAt = &expiresAt
+		promoted = next
+		return nil
+	})
+	return promoted, err
+}
+
+func (r *gormRepository) CountAhead(ctx context.Context, entry *domain.WaitlistEntry) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.WaitlistEntry{}).
+		Where("vehicle_id = ? AND status = ? AND (created_at < ? OR (created_at = ? AND id < ?))",
+			entry.VehicleID, domain.WaitlistStatusWaiting, entry.CreatedAt, entry.CreatedAt, entry.ID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *gormRepository) ListByUser(ctx context.Context, userID int64) ([]*domain.WaitlistEntry, error) {
+	var entries []*domain.WaitlistEntry
+	err := r.db.WithContext(ctx).
+		Preload("Vehicle").
+		Where("user_id = ? AND status IN ?", userID, activeStatuses).
+		Order("created_at asc").
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *gormRepository) ListByVehicle(ctx context.Context, vehicleID int64) ([]*domain.WaitlistEntry, error) {
+	var entries []*domain.WaitlistEntry
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("vehicle_id = ? AND status IN ?", vehicleID, activeStatuses).
+		Order("created_at asc, id asc").
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *gormRepository) FindExpiredHolds(ctx context.Context, now time.Time) ([]*domain.WaitlistEntry, error) {
+	var entries []*domain.WaitlistEntry
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND hold_expires_at <= ?", domain.WaitlistStatusHolding, now).
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *gormRepository) first(query *gorm.DB) (*domain.WaitlistEntry, error) {
+	var entry domain.WaitlistEntry
+	if err := query.First(&entry).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
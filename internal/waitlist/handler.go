@@ -0,0 +1,127 @@
+package waitlist
+
+import (
+	"encoding/json"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/pkg/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(s Service) *Handler {
+	return &Handler{service: s}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router, authMiddleware func(http.Handler) http.Handler, authz *middleware.Authorizer) {
+	customerOnly := authz.Require(domain.RoleCustomer)
+	staffOnly := authz.Require(domain.RoleStaff, domain.RoleAdmin)
+
+	// Like vehicle images, the waitlist hangs off the vehicle it belongs to.
+	r := router.PathPrefix("/api/vehicles/{vehicleID}/waitlist").Subrouter()
+	r.Use(authMiddleware)
+	r.Handle("", customerOnly(http.HandlerFunc(h.joinWaitlistHandler))).Methods("POST")
+	r.Handle("", customerOnly(http.HandlerFunc(h.leaveWaitlistHandler))).Methods("DELETE")
+	r.Handle("", staffOnly(http.HandlerFunc(h.listVehicleWaitlistHandler))).Methods("GET")
+
+	me := router.PathPrefix("/api/waitlist").Subrouter()
+	me.Use(authMiddleware, customerOnly)
+	me.HandleFunc("/me", h.listMyWaitlistHandler).Methods("GET")
+}
+
+func (h *Handler) joinWaitlistHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	vehicleID, err := strconv.ParseInt(mux.Vars(r)["vehicleID"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid vehicle ID", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.service.Join(r.Context(), userID, vehicleID)
+	if err != nil {
+		switch err.Error() {
+		case "vehicle not found":
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case "you are already on the waitlist for this vehicle",
+			"vehicle is available, you can book it directly",
+			"vehicle has already been sold":
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to join waitlist", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+func (h *Handler) leaveWaitlistHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+	vehicleID, err := strconv.ParseInt(mux.Vars(r)["vehicleID"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid vehicle ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Leave(r.Context(), userID, vehicleID); err != nil {
+		if err.Error() == "waitlist entry not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to leave waitlist", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) listVehicleWaitlistHandler(w http.ResponseWriter, r *http.Request) {
+	vehicleID, err := strconv.ParseInt(mux.Vars(r)["vehicleID"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid vehicle ID", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.service.ListForVehicle(r.Context(), vehicleID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve waitlist", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entries)
+}
+
+func (h *Handler) listMyWaitlistHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		http.Error(w, "Could not retrieve user ID from token", http.StatusInternalServerError)
+		return
+	}
+
+	entries, err := h.service.ListForUser(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve waitlist", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entries)
+}
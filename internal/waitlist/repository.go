@@ -0,0 +1,32 @@
+package waitlist
+
+import (
+	"context"
+	"mobigo-backend/internal/domain"
+	"time"
+)
+
+// Repository defines the interface for waitlist data operations.
+type Repository interface {
+	Create(ctx context.Context, entry *domain.WaitlistEntry) error
+	Update(ctx context.Context, entry *domain.WaitlistEntry) error
+	// GetActiveEntry returns the user's waiting or holding entry for a vehicle, if any.
+	GetActiveEntry(ctx context.Context, userID, vehicleID int64) (*domain.WaitlistEntry, error)
+	// GetActiveHold returns the unexpired hold on a vehicle, if any.
+	GetActiveHold(ctx context.Context, vehicleID int64, now time.Time) (*domain.WaitlistEntry, error)
+	// GetNextWaiting returns the longest-waiting entry for a vehicle, if any.
+	GetNextWaiting(ctx context.Context, vehicleID int64) (*domain.WaitlistEntry, error)
+	// PromoteNext gives the longest-waiting entry for a vehicle a hold until expiresAt and
+	// returns it. It locks the vehicle row so concurrent releases promote one customer at
+	// a time, and does nothing (returning nil) if the vehicle is no longer available, an
+	// unexpired hold exists or nobody is waiting.
+	PromoteNext(ctx context.Context, vehicleID int64, now, expiresAt time.Time) (*domain.WaitlistEntry, error)
+	// CountAhead counts the waiting entries queued before the given entry.
+	CountAhead(ctx context.Context, entry *domain.WaitlistEntry) (int64, error)
+	// ListByUser returns a user's active entries with their vehicles.
+	ListByUser(ctx context.Context, userID int64) ([]*domain.WaitlistEntry, error)
+	// ListByVehicle returns a vehicle's active entries in queue order with their users.
+	ListByVehicle(ctx context.Context, vehicleID int64) ([]*domain.WaitlistEntry, error)
+	// FindExpiredHolds returns holding entries whose hold ended before now.
+	FindExpiredHolds(ctx context.Context, now time.Time) ([]*domain.WaitlistEntry, error)
+}
@@ -0,0 +1,259 @@
+package waitlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mobigo-backend/internal/domain"
+	"mobigo-backend/internal/vehicle"
+	"time"
+)
+
+// Notifier is what the waitlist needs to tell customers about their holds.
+type Notifier interface {
+	Notify(ctx context.Context, userID int64, notificationType domain.NotificationType, title, message string) error
+}
+
+// Service manages the queue of customers waiting for a booked vehicle.
+// When the vehicle becomes available again, the customer at the head of the
+// queue is given a hold: for holdDuration, nobody else can book the vehicle.
+type Service interface {
+	Join(ctx context.Context, userID, vehicleID int64) (*domain.WaitlistEntry, error)
+	Leave(ctx context.Context, userID, vehicleID int64) error
+	ListForUser(ctx context.Context, userID int64) ([]*domain.WaitlistEntry, error)
+	ListForVehicle(ctx context.Context, vehicleID int64) ([]*domain.WaitlistEntry, error)
+	// CheckBookingAllowed returns an error if another customer holds the vehicle.
+	CheckBookingAllowed(ctx context.Context, userID, vehicleID int64) error
+	// HoldConsumed takes the user off the vehicle's waitlist once they have booked it.
+	HoldConsumed(ctx context.Context, userID, vehicleID int64) error
+	// VehicleReleased gives the next customer in line a hold if the vehicle is available
+	// and has no open booking.
+	VehicleReleased(ctx context.Context, vehicleID int64) error
+	// ExpireHolds ends every hold that has run out and passes the vehicle on to the next in line.
+	ExpireHolds(ctx context.Context) error
+}
+
+type service struct {
+	repo         Repository
+	vehicleRepo  vehicle.Repository
+	notifier     Notifier
+	holdDuration time.Duration
+}
+
+func NewService(repo Repository, vehicleRepo vehicle.Repository, notifier Notifier, holdDuration time.Duration) Service {
+	return &service{
+		repo:         repo,
+		vehicleRepo:  vehicleRepo,
+		notifier:     notifier,
+		holdDuration: holdDuration,
+	}
+}
+
+func (s *service) Join(ctx context.Context, userID, vehicleID int64) (*domain.WaitlistEntry, error) {
+	v, err := s.vehicleRepo.GetVehicleByID(ctx, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, errors.New("vehicle not found")
+	}
+	if v.Status == domain.VehicleStatusSold || v.Status == domain.VehicleStatusOnInstallment {
+		return nil, errors.New("vehicle has already been sold")
+	}
+
+	existing, err := s.repo.GetActiveEntry(ctx, userID, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("you are already on the waitlist for this vehicle")
+	}
+
+	if v.Status == domain.VehicleStatusAvailable {
+		hold, err := s.repo.GetActiveHold(ctx, vehicleID, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if hold == nil {
+			return nil, errors.New("vehicle is available, you can book it directly")
+		}
+	}
+
+	entry := &domain.WaitlistEntry{
+		VehicleID: vehicleID,
+		UserID:    userID,
+		Status:    domain.WaitlistStatusWaiting,
+	}
+	if err := s.repo.Create(ctx, entry); err != nil {
+		return nil, err
+	}
+	if err := s.fillPosition(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *service) Leave(ctx context.Context, userID, vehicleID int64) error {
+	entry, err := s.repo.GetActiveEntry(ctx, userID, vehicleID)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return errors.New("waitlist entry not found")
+	}
+
+	wasHolding := entry.Status == domain.WaitlistStatusHolding
+	entry.Status = domain.WaitlistStatusCancelled
+	entry.HoldExpiresAt = nil
+	if err := s.repo.Update(ctx, entry); err != nil {
+		return err
+	}
+
+	// Giving up a hold passes the vehicle straight to the next customer.
+	if wasHolding {
+		return s.promoteNext(ctx, vehicleID)
+	}
+	return nil
+}
+
+func (s *service) ListForUser(ctx context.Context, userID int64) ([]*domain.WaitlistEntry, error) {
+	entries, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if err := s.fillPosition(ctx, entry); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+func (s *service) ListForVehicle(ctx context.Context, vehicleID int64) ([]*domain.WaitlistEntry, error) {
+	entries, err := s.repo.ListByVehicle(ctx, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	var position int64
+	for _, entry := range entries {
+		if entry.Status == domain.WaitlistStatusWaiting {
+			position++
+			entry.Position = position
+		}
+	}
+	return entries, nil
+}
+
+// CheckBookingAllowed only reads: promotion happens when the vehicle is released, when
+// a hold expires or is given up, never as a side effect of someone trying to book.
+func (s *service) CheckBookingAllowed(ctx context.Context, userID, vehicleID int64) error {
+	hold, err := s.repo.GetActiveHold(ctx, vehicleID, time.Now())
+	if err != nil {
+		return err
+	}
+	if hold != nil {
+		if hold.UserID != userID {
+			return errors.New("vehicle is on hold for another customer")
+		}
+		return nil
+	}
+
+	// Nobody holds the vehicle yet, e.g. a hold ran out before the expiry job or a
+	// pending booking kept the queue from moving. The queue still goes first.
+	next, err := s.repo.GetNextWaiting(ctx, vehicleID)
+	if err != nil {
+		return err
+	}
+	if next != nil && next.UserID != userID {
+		return errors.New("vehicle is being offered to customers on its waitlist")
+	}
+	return nil
+}
+
+func (s *service) HoldConsumed(ctx context.Context, userID, vehicleID int64) error {
+	entry, err := s.repo.GetActiveEntry(ctx, userID, vehicleID)
+	if err != nil || entry == nil {
+		return err
+	}
+	entry.Status = domain.WaitlistStatusFulfilled
+	entry.HoldExpiresAt = nil
+	return s.repo.Update(ctx, entry)
+}
+
+func (s *service) VehicleReleased(ctx context.Context, vehicleID int64) error {
+	return s.promoteNext(ctx, vehicleID)
+}
+
+func (s *service) ExpireHolds(ctx context.Context) error {
+	expired, err := s.repo.FindExpiredHolds(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range expired {
+		entry.Status = domain.WaitlistStatusExpired
+		if err := s.repo.Update(ctx, entry); err != nil {
+			log.Printf("WAITLIST ERROR: Failed to expire hold %d: %v", entry.ID, err)
+			continue
+		}
+		s.notify(ctx, entry.UserID, domain.NotificationTypeWaitlistHoldExpired,
+			"Your vehicle hold has expired",
+			"You did not book the vehicle in time, so it has been offered to the next customer on the waitlist.")
+
+		if err := s.promoteNext(ctx, entry.VehicleID); err != nil {
+			log.Printf("WAITLIST ERROR: Failed to promote next customer for vehicle %d: %v", entry.VehicleID, err)
+		}
+	}
+	return nil
+}
+
+// promoteNext gives the longest-waiting customer a hold on the vehicle, provided
+// the vehicle is available, nobody is already holding it and it has no open booking.
+func (s *service) promoteNext(ctx context.Context, vehicleID int64) error {
+	v, err := s.vehicleRepo.GetVehicleByID(ctx, vehicleID)
+	if err != nil {
+		return err
+	}
+	if v == nil || v.Status != domain.VehicleStatusAvailable {
+		return nil
+	}
+	// A pending booking leaves the vehicle available, but it is already spoken for.
+	deal, err := s.vehicleRepo.ActiveDeal(ctx, vehicleID)
+	if err != nil || deal != "" {
+		return err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.holdDuration)
+	next, err := s.repo.PromoteNext(ctx, vehicleID, now, expiresAt)
+	if err != nil || next == nil {
+		return err
+	}
+
+	s.notify(ctx, next.UserID, domain.NotificationTypeWaitlistHold,
+		"A vehicle you are waiting for is available",
+		fmt.Sprintf("The %d %s %s is now available and is being held for you until %s. Book it before then to keep your place.",
+			v.Year, v.Make, v.Model, expiresAt.Format("02 Jan 2006 15:04")))
+	return nil
+}
+
+func (s *service) fillPosition(ctx context.Context, entry *domain.WaitlistEntry) error {
+	if entry.Status != domain.WaitlistStatusWaiting {
+		return nil
+	}
+	ahead, err := s.repo.CountAhead(ctx, entry)
+	if err != nil {
+		return err
+	}
+	entry.Position = ahead + 1
+	return nil
+}
+
+// notify delivers a notification without failing the surrounding operation;
+// a missed notification must not undo a hold that was already granted.
+func (s *service) notify(ctx context.Context, userID int64, notificationType domain.NotificationType, title, message string) {
+	if err := s.notifier.Notify(ctx, userID, notificationType, title, message); err != nil {
+		log.Printf("WAITLIST ERROR: Failed to notify user %d: %v", userID, err)
+	}
+}
@@ -0,0 +1,12 @@
+CREATE TABLE waitlist_entries (
+    id SERIAL PRIMARY KEY,
+    vehicle_id INT NOT NULL REFERENCES vehicles(id),
+    user_id INT NOT NULL REFERENCES users(id),
+    status VARCHAR(50) NOT NULL DEFAULT 'waiting',
+    hold_expires_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_waitlist_entries_vehicle_id ON waitlist_entries(vehicle_id);
+CREATE INDEX idx_waitlist_entries_user_id ON waitlist_entries(user_id);
@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN deactivated_at;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE staff_invitations (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    role_id INT NOT NULL REFERENCES roles(id),
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    invited_by_id INT NOT NULL REFERENCES users(id),
+    expires_at TIMESTAMP NOT NULL,
+    accepted_at TIMESTAMP NULL,
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_staff_invitations_email ON staff_invitations(email);
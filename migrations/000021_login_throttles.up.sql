@@ -0,0 +1,9 @@
+CREATE TABLE login_throttles (
+    id SERIAL PRIMARY KEY,
+    throttle_key VARCHAR(320) NOT NULL UNIQUE,
+    failed_attempts INT NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,13 @@
+CREATE TABLE audit_logs (
+    id SERIAL PRIMARY KEY,
+    actor_id INT NULL REFERENCES users(id),
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(50) NULL,
+    target_id INT NULL,
+    ip_address VARCHAR(45) NULL,
+    details TEXT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_logs_actor_id ON audit_logs(actor_id);
+CREATE INDEX idx_audit_logs_action ON audit_logs(action);
@@ -0,0 +1,14 @@
+ALTER TABLE users ADD COLUMN totp_secret VARCHAR(64) NULL;
+ALTER TABLE users ADD COLUMN totp_enabled_at TIMESTAMP NULL;
+ALTER TABLE users ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN two_factor_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes(user_id);
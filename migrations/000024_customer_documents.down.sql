@@ -0,0 +1 @@
+DROP TABLE IF EXISTS customer_documents;
\ No newline at end of file
@@ -0,0 +1,18 @@
+CREATE TABLE customer_documents (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    type VARCHAR(50) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    storage_path VARCHAR(512) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    rejection_reason TEXT NULL,
+    reviewed_by_id INT NULL REFERENCES users(id),
+    reviewed_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_customer_documents_user_id ON customer_documents(user_id);
+CREATE INDEX idx_customer_documents_status ON customer_documents(status);
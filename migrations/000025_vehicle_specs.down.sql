@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS vehicle_features;
+ALTER TABLE vehicles DROP COLUMN service_history;
+ALTER TABLE vehicles DROP COLUMN vehicle_condition;
+ALTER TABLE vehicles DROP COLUMN plate_region;
+ALTER TABLE vehicles DROP COLUMN seats;
+ALTER TABLE vehicles DROP COLUMN color;
+ALTER TABLE vehicles DROP COLUMN body_type;
+ALTER TABLE vehicles DROP COLUMN fuel_type;
+ALTER TABLE vehicles DROP COLUMN transmission;
+ALTER TABLE vehicles DROP COLUMN engine_capacity;
+ALTER TABLE vehicles DROP COLUMN mileage;
\ No newline at end of file
@@ -0,0 +1,19 @@
+ALTER TABLE vehicles ADD COLUMN mileage INT NOT NULL DEFAULT 0;
+ALTER TABLE vehicles ADD COLUMN engine_capacity INT NOT NULL DEFAULT 0;
+ALTER TABLE vehicles ADD COLUMN transmission VARCHAR(20) NULL;
+ALTER TABLE vehicles ADD COLUMN fuel_type VARCHAR(20) NULL;
+ALTER TABLE vehicles ADD COLUMN body_type VARCHAR(20) NULL;
+ALTER TABLE vehicles ADD COLUMN color VARCHAR(50) NULL;
+ALTER TABLE vehicles ADD COLUMN seats INT NOT NULL DEFAULT 0;
+ALTER TABLE vehicles ADD COLUMN plate_region VARCHAR(10) NULL;
+ALTER TABLE vehicles ADD COLUMN vehicle_condition VARCHAR(10) NOT NULL DEFAULT 'used';
+ALTER TABLE vehicles ADD COLUMN service_history VARCHAR(20) NULL;
+
+CREATE TABLE vehicle_features (
+    id SERIAL PRIMARY KEY,
+    vehicle_id INT NOT NULL REFERENCES vehicles(id),
+    name VARCHAR(50) NOT NULL,
+    UNIQUE (vehicle_id, name)
+);
+
+CREATE INDEX idx_vehicle_features_name ON vehicle_features(name);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS discount_campaign_vehicles;
+DROP TABLE IF EXISTS discount_campaigns;
+DROP TABLE IF EXISTS vehicle_price_changes;
\ No newline at end of file
@@ -0,0 +1,38 @@
+CREATE TABLE vehicle_price_changes (
+    id SERIAL PRIMARY KEY,
+    vehicle_id INT NOT NULL REFERENCES vehicles(id),
+    old_price DECIMAL(15,2) NOT NULL,
+    new_price DECIMAL(15,2) NOT NULL,
+    changed_by_id INT NOT NULL REFERENCES users(id),
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_vehicle_price_changes_vehicle_id ON vehicle_price_changes(vehicle_id);
+
+CREATE TABLE discount_campaigns (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    discount_type VARCHAR(20) NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    make VARCHAR(255) NULL,
+    model VARCHAR(255) NULL,
+    year_min INT NOT NULL DEFAULT 0,
+    year_max INT NOT NULL DEFAULT 0,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    created_by_id INT NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP NULL
+);
+
+CREATE INDEX idx_discount_campaigns_starts_at ON discount_campaigns(starts_at);
+CREATE INDEX idx_discount_campaigns_ends_at ON discount_campaigns(ends_at);
+CREATE INDEX idx_discount_campaigns_deleted_at ON discount_campaigns(deleted_at);
+
+CREATE TABLE discount_campaign_vehicles (
+    campaign_id INT NOT NULL REFERENCES discount_campaigns(id),
+    vehicle_id INT NOT NULL REFERENCES vehicles(id),
+    PRIMARY KEY (campaign_id, vehicle_id)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE agreements DROP COLUMN trade_in_credit;
+ALTER TABLE agreements DROP COLUMN trade_in_id;
+DROP TABLE IF EXISTS trade_in_photos;
+DROP TABLE IF EXISTS trade_ins;
\ No newline at end of file
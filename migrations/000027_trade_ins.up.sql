@@ -0,0 +1,39 @@
+CREATE TABLE trade_ins (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    make VARCHAR(255) NOT NULL,
+    model VARCHAR(255) NOT NULL,
+    year INT NOT NULL,
+    mileage INT NOT NULL DEFAULT 0,
+    plate_number VARCHAR(20) NULL,
+    vin VARCHAR(17) NULL,
+    description TEXT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'submitted',
+    appraised_value DECIMAL(15,2) NOT NULL DEFAULT 0,
+    appraisal_notes TEXT NULL,
+    appraised_by_id INT NULL REFERENCES users(id),
+    appraised_at TIMESTAMP NULL,
+    offer_expires_at TIMESTAMP NULL,
+    agreement_id INT NULL REFERENCES agreements(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_trade_ins_user_id ON trade_ins(user_id);
+CREATE INDEX idx_trade_ins_status ON trade_ins(status);
+
+CREATE TABLE trade_in_photos (
+    id SERIAL PRIMARY KEY,
+    trade_in_id INT NOT NULL REFERENCES trade_ins(id),
+    file_name VARCHAR(255) NOT NULL,
+    storage_path VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_trade_in_photos_trade_in_id ON trade_in_photos(trade_in_id);
+
+-- The unique index stops one trade-in being credited on two agreements.
+ALTER TABLE agreements ADD COLUMN trade_in_id INT NULL REFERENCES trade_ins(id);
+ALTER TABLE agreements ADD COLUMN trade_in_credit DECIMAL(15,2) NOT NULL DEFAULT 0;
+CREATE UNIQUE INDEX idx_agreements_trade_in_id ON agreements(trade_in_id);
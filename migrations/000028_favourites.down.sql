@@ -0,0 +1 @@
+DROP TABLE IF EXISTS favourite_vehicles;
\ No newline at end of file
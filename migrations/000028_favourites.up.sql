@@ -0,0 +1,9 @@
+CREATE TABLE favourite_vehicles (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    vehicle_id INT NOT NULL REFERENCES vehicles(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_favourite_vehicles_user_vehicle ON favourite_vehicles(user_id, vehicle_id);
+CREATE INDEX idx_favourite_vehicles_vehicle_id ON favourite_vehicles(vehicle_id);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS vehicle_transfers;
+ALTER TABLE schedules DROP COLUMN branch_id;
+ALTER TABLE users DROP COLUMN branch_id;
+ALTER TABLE vehicles DROP COLUMN branch_id;
+DROP TABLE IF EXISTS branches;
\ No newline at end of file
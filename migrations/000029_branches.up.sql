@@ -0,0 +1,29 @@
+CREATE TABLE branches (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    address TEXT NULL,
+    phone_number VARCHAR(30) NULL,
+    viewing_capacity INT NOT NULL DEFAULT 1,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Existing vehicles, staff and schedules keep a NULL branch until they are assigned one.
+ALTER TABLE vehicles ADD COLUMN branch_id INT NULL REFERENCES branches(id);
+CREATE INDEX idx_vehicles_branch_id ON vehicles(branch_id);
+ALTER TABLE users ADD COLUMN branch_id INT NULL REFERENCES branches(id);
+CREATE INDEX idx_users_branch_id ON users(branch_id);
+ALTER TABLE schedules ADD COLUMN branch_id INT NULL REFERENCES branches(id);
+CREATE INDEX idx_schedules_branch_id ON schedules(branch_id);
+
+CREATE TABLE vehicle_transfers (
+    id SERIAL PRIMARY KEY,
+    vehicle_id INT NOT NULL REFERENCES vehicles(id),
+    from_branch_id INT NULL REFERENCES branches(id),
+    to_branch_id INT NOT NULL REFERENCES branches(id),
+    transferred_by_id INT NOT NULL REFERENCES users(id),
+    reason TEXT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_vehicle_transfers_vehicle_id ON vehicle_transfers(vehicle_id);
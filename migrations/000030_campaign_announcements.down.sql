@@ -0,0 +1 @@
+ALTER TABLE discount_campaigns DROP COLUMN announced_at;
\ No newline at end of file
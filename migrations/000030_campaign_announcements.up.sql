@@ -0,0 +1,6 @@
+-- Set once the price drops a running campaign causes have been announced to customers
+-- who saved the vehicles; cleared when the campaign is edited.
+ALTER TABLE discount_campaigns ADD COLUMN announced_at TIMESTAMP NULL;
+
+-- Campaigns that have already started are not announced after the fact.
+UPDATE discount_campaigns SET announced_at = NOW() WHERE starts_at <= NOW();
@@ -0,0 +1,13 @@
+// Package likepattern builds SQL LIKE patterns from user input. Queries using them
+// should name the escape character explicitly: LIKE ? ESCAPE '\'.
+package likepattern
+
+import "strings"
+
+var escaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Contains returns a pattern matching s anywhere in a value, with any % and _ in s
+// matched literally.
+func Contains(s string) string {
+	return "%" + escaper.Replace(s) + "%"
+}
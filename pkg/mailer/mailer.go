@@ -0,0 +1,76 @@
+// Package mailer sends transactional email. Services depend on the Mailer
+// interface so the delivery mechanism can be swapped per environment.
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers email messages.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// logMailer writes messages to the application log instead of sending them.
+type logMailer struct{}
+
+// NewLogMailer creates a Mailer for local development that only logs messages.
+func NewLogMailer() Mailer {
+	return &logMailer{}
+}
+
+func (m *logMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("MAIL: to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// fileMailer writes each message to its own file in a directory.
+type fileMailer struct {
+	dir string
+	mu  sync.Mutex
+	seq int
+}
+
+// NewFileMailer creates a Mailer for local development that saves every message as
+// a .eml file in dir, so links in them can be opened by hand.
+func NewFileMailer(dir string) (Mailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &fileMailer{dir: dir}, nil
+}
+
+func (m *fileMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	m.seq++
+	name := fmt.Sprintf("%s-%03d.eml", time.Now().Format("20060102-150405"), m.seq)
+	m.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(msg.Body)
+
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		return err
+	}
+	log.Printf("MAIL: to=%s subject=%q saved to %s", msg.To, msg.Subject, path)
+	return nil
+}
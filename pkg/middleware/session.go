@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// sessionIDContextKey is the context key under which SessionAuthMiddleware stores the session ID.
+type sessionIDContextKey struct{}
+
+// SessionValidator reports whether a login session is still active.
+type SessionValidator interface {
+	IsSessionActive(ctx context.Context, userID, sessionID int64) (bool, error)
+}
+
+// SessionAuthMiddleware validates the bearer access token like JWTAuthMiddleware and
+// additionally checks that the session it was issued for has not been revoked or
+// expired, so logging out takes effect immediately. On success it puts the user ID
+// (under UserIDKey) and the session ID in the request context.
+func SessionAuthMiddleware(jwtSecret string, sessions SessionValidator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+			if authHeader == "" || tokenString == authHeader {
+				http.Error(w, "Authorization header is required", http.StatusUnauthorized)
+				return
+			}
+
+			token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+					return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+				}
+				return []byte(jwtSecret), nil
+			})
+			if err != nil || !token.Valid {
+				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+
+			claims, ok := token.Claims.(jwt.MapClaims)
+			if !ok {
+				http.Error(w, "Invalid token claims", http.StatusUnauthorized)
+				return
+			}
+			// JSON numbers are decoded as float64.
+			userID, ok := claims["user_id"].(float64)
+			if !ok {
+				http.Error(w, "Invalid token claims", http.StatusUnauthorized)
+				return
+			}
+			sessionID, ok := claims["sid"].(float64)
+			if !ok {
+				// Tokens issued before sessions existed cannot be revoked, so they are refused.
+				http.Error(w, "Invalid token claims", http.StatusUnauthorized)
+				return
+			}
+
+			active, err := sessions.IsSessionActive(r.Context(), int64(userID), int64(sessionID))
+			if err != nil {
+				http.Error(w, "Could not validate session", http.StatusInternalServerError)
+				return
+			}
+			if !active {
+				http.Error(w, "Session has been revoked or has expired", http.StatusUnauthorized)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), UserIDKey, int64(userID))
+			ctx = context.WithValue(ctx, sessionIDContextKey{}, int64(sessionID))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// SessionIDFromContext returns the session ID stored by SessionAuthMiddleware.
+func SessionIDFromContext(ctx context.Context) (int64, bool) {
+	sessionID, ok := ctx.Value(sessionIDContextKey{}).(int64)
+	return sessionID, ok
+}
@@ -0,0 +1,89 @@
+// Package privatefile stores uploads in a directory that is not publicly served.
+// Files are kept under random names, and their format is detected from the content
+// rather than trusted from the uploaded file name.
+package privatefile
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// ErrUnsupportedType is returned when the content is not one of the store's formats.
+	ErrUnsupportedType = errors.New("unsupported file type")
+	// ErrTooLarge is returned when the content is longer than the store's MaxSize.
+	ErrTooLarge = errors.New("file is too large")
+)
+
+// Store saves files into Dir.
+type Store struct {
+	Dir string
+	// Types maps each accepted content type, as detected by http.DetectContentType,
+	// to the extension the stored file gets.
+	Types   map[string]string
+	MaxSize int64
+}
+
+// File is a stored file.
+type File struct {
+	Path        string
+	ContentType string
+	Size        int64
+}
+
+// Save writes content to a new file. On error nothing is left behind; callers that
+// fail to record the returned file should remove it themselves.
+func (s Store) Save(content io.Reader) (*File, error) {
+	// Sniff the format from the first bytes rather than trusting the file name.
+	head := make([]byte, 512)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	ext, ok := s.Types[contentType]
+	if !ok {
+		return nil, ErrUnsupportedType
+	}
+
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return nil, err
+	}
+	name, err := randomName(ext)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(s.Dir, name)
+	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	size, err := io.Copy(dst, io.MultiReader(bytes.NewReader(head), io.LimitReader(content, s.MaxSize-int64(len(head))+1)))
+	closeErr := dst.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil && size > s.MaxSize {
+		err = ErrTooLarge
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return &File{Path: path, ContentType: contentType, Size: size}, nil
+}
+
+func randomName(ext string) (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw) + ext, nil
+}
@@ -0,0 +1,24 @@
+// Package securetoken generates the random tokens sent to users in links and
+// feed URLs, and hashes them for storage. Only the hash of a token is ever stored.
+package securetoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Generate returns a random 256-bit token, hex encoded.
+func Generate() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// Hash returns the SHA-256 hash of a token, hex encoded.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,16 @@
+// Package timeparam parses the date and time query parameters accepted by list endpoints.
+package timeparam
+
+import "time"
+
+// DateLayout is the layout of date-only parameters.
+const DateLayout = "2006-01-02"
+
+// Parse accepts an RFC3339 timestamp or a YYYY-MM-DD date, which is read as
+// midnight local time.
+func Parse(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(DateLayout, raw, time.Local)
+}
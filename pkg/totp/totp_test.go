@@ -0,0 +1,85 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 seed from RFC 6238 appendix B, "12345678901234567890".
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+// The appendix lists 8-digit codes; Digits is 6, so these are their last six digits.
+func TestCodeAtRFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := CodeAt(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("CodeAt(%d): %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("CodeAt(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCodeAtInvalidSecret(t *testing.T) {
+	if _, err := CodeAt("not base32!", 1); err == nil {
+		t.Error("CodeAt accepted an invalid secret")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+	code, err := CodeAt(rfcSecret, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous, _ := CodeAt(rfcSecret, step-1)
+	tooOld, _ := CodeAt(rfcSecret, step-2)
+
+	tests := []struct {
+		name     string
+		code     string
+		skew     int
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", code, 1, step, true},
+		{"surrounding spaces", " " + code + " ", 1, step, true},
+		{"previous step within skew", previous, 1, step - 1, true},
+		{"previous step without skew", previous, 0, 0, false},
+		{"outside skew", tooOld, 1, 0, false},
+		{"wrong length", code[:5], 1, 0, false},
+		{"wrong code", "000000", 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := Validate(rfcSecret, tt.code, now, tt.skew)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("Validate = (%d, %v), want (%d, %v)", gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateSecretRoundTrips(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CodeAt(secret, 1); err != nil {
+		t.Errorf("generated secret is not usable: %v", err)
+	}
+}
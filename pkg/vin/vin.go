@@ -0,0 +1,218 @@
+// Package vin validates and decodes ISO 3779 vehicle identification numbers.
+// Manufacturers are looked up in an embedded WMI table, so decoding works offline.
+package vin
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Length is the length of a VIN; cars built since 1981 all use 17 characters.
+const Length = 17
+
+var (
+	// ErrInvalidFormat is returned for VINs that are not 17 characters of the VIN alphabet.
+	ErrInvalidFormat = errors.New("VIN must be 17 characters of digits and letters other than I, O and Q")
+	// ErrCheckDigit is returned when the check digit (character 9) does not match.
+	ErrCheckDigit = errors.New("VIN check digit does not match")
+)
+
+//go:embed wmi.csv
+var wmiCSV string
+
+// Manufacturer is a row of the WMI table.
+type Manufacturer struct {
+	Make    string   `json:"make"`
+	Country string   `json:"country"`
+	aliases []string // Other names for the make, such as "Mercedes" for "Mercedes-Benz"
+}
+
+// manufacturers is the WMI table, keyed by the first three VIN characters.
+var manufacturers = loadWMI(wmiCSV)
+
+// Info is what can be read from a VIN without contacting the manufacturer.
+type Info struct {
+	VIN          string        `json:"vin"`
+	WMI          string        `json:"wmi"`
+	Manufacturer *Manufacturer `json:"manufacturer,omitempty"` // nil if the WMI is not in our table
+	// ModelYears lists the years the year code can stand for. The code repeats every
+	// 30 years, so there are usually two candidates up to next year.
+	ModelYears []int  `json:"model_years"`
+	PlantCode  string `json:"plant_code"` // Character 11; its meaning is manufacturer specific
+	Serial     string `json:"serial"`
+	// CheckDigitValid reports whether character 9 matches. It is only required for
+	// VINs from regions that mandate it; see Validate.
+	CheckDigitValid bool `json:"check_digit_valid"`
+}
+
+// transliteration gives each VIN character its value for the check digit calculation.
+var transliteration = map[byte]int{
+	'A': 1, 'B': 2, 'C': 3, 'D': 4, 'E': 5, 'F': 6, 'G': 7, 'H': 8,
+	'J': 1, 'K': 2, 'L': 3, 'M': 4, 'N': 5, 'P': 7, 'R': 9,
+	'S': 2, 'T': 3, 'U': 4, 'V': 5, 'W': 6, 'X': 7, 'Y': 8, 'Z': 9,
+}
+
+// weights are the check digit weights for each position; position 9 is the check digit itself.
+var weights = [Length]int{8, 7, 6, 5, 4, 3, 2, 10, 0, 9, 8, 7, 6, 5, 4, 3, 2}
+
+// yearCodes are the model year codes in order, starting at 1980. The cycle restarts in 2010.
+const yearCodes = "ABCDEFGHJKLMNPRSTVWXY123456789"
+
+// Normalize upper-cases a VIN and strips surrounding whitespace.
+func Normalize(v string) string {
+	return strings.ToUpper(strings.TrimSpace(v))
+}
+
+// Validate checks the format of a normalized VIN. The check digit is enforced for
+// North American and Chinese VINs, where it is mandatory; elsewhere many
+// manufacturers put other data in character 9, so it is not enforced.
+func Validate(v string) error {
+	if len(v) != Length {
+		return ErrInvalidFormat
+	}
+	for i := 0; i < Length; i++ {
+		c := v[i]
+		if c >= '0' && c <= '9' {
+			continue
+		}
+		if _, ok := transliteration[c]; !ok {
+			return ErrInvalidFormat
+		}
+	}
+	if regulatedRegion(v) && !checkDigitValid(v) {
+		return ErrCheckDigit
+	}
+	return nil
+}
+
+// Decode validates a VIN and reads the manufacturer, model year and plant from it.
+func Decode(v string) (*Info, error) {
+	v = Normalize(v)
+	if err := Validate(v); err != nil {
+		return nil, err
+	}
+
+	info := &Info{
+		VIN:             v,
+		WMI:             v[:3],
+		ModelYears:      modelYears(v[9], time.Now().Year()+1),
+		PlantCode:       v[10:11],
+		Serial:          v[11:],
+		CheckDigitValid: checkDigitValid(v),
+	}
+	if m, ok := manufacturers[info.WMI]; ok {
+		info.Manufacturer = &m
+	}
+	return info, nil
+}
+
+// CheckMake reports whether the submitted make matches the decoded manufacturer.
+// Spacing, punctuation and case are ignored. The submitted make must be the whole
+// make or one of its aliases in the WMI table ("Mercedes" for "Mercedes-Benz"). It is
+// always true for unknown WMIs.
+func (i *Info) CheckMake(make string) bool {
+	if i.Manufacturer == nil {
+		return true
+	}
+	submitted := simplify(make)
+	if submitted == "" {
+		return false
+	}
+	if submitted == simplify(i.Manufacturer.Make) {
+		return true
+	}
+	for _, alias := range i.Manufacturer.aliases {
+		if submitted == simplify(alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckYear reports whether the submitted year is one the year code can stand for.
+// Character 10 is only a mandatory year code in the regions that mandate the check
+// digit, so it is always true for other VINs, and for codes that are not year codes.
+func (i *Info) CheckYear(year int) bool {
+	if !regulatedRegion(i.VIN) || len(i.ModelYears) == 0 {
+		return true
+	}
+	for _, y := range i.ModelYears {
+		if y == year {
+			return true
+		}
+	}
+	return false
+}
+
+// regulatedRegion reports whether the VIN was assigned in a region that mandates the
+// check digit and model year code: North America (1-5) or China (L).
+func regulatedRegion(v string) bool {
+	return (v[0] >= '1' && v[0] <= '5') || v[0] == 'L'
+}
+
+// checkDigitValid computes the weighted sum of the VIN modulo 11 and compares it with
+// character 9, where a remainder of 10 is written as X.
+func checkDigitValid(v string) bool {
+	sum := 0
+	for i := 0; i < Length; i++ {
+		c := v[i]
+		value, ok := transliteration[c]
+		if !ok {
+			value = int(c - '0')
+		}
+		sum += value * weights[i]
+	}
+	want := byte('0' + sum%11)
+	if sum%11 == 10 {
+		want = 'X'
+	}
+	return v[8] == want
+}
+
+// modelYears returns the years, up to maxYear, that a year code can stand for.
+func modelYears(code byte, maxYear int) []int {
+	i := strings.IndexByte(yearCodes, code)
+	if i < 0 {
+		return nil // 0, U and Z are not used as year codes
+	}
+	var years []int
+	for y := 1980 + i; y <= maxYear; y += len(yearCodes) {
+		years = append(years, y)
+	}
+	return years
+}
+
+// simplify lower-cases a make and drops everything but letters and digits.
+func simplify(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// loadWMI parses the embedded table. Lines starting with # are comments. A malformed
+// table is a build mistake, so it panics at start-up rather than failing silently.
+func loadWMI(data string) map[string]Manufacturer {
+	r := csv.NewReader(strings.NewReader(data))
+	r.Comment = '#'
+	records, err := r.ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("vin: invalid WMI table: %v", err))
+	}
+	table := make(map[string]Manufacturer, len(records))
+	for _, rec := range records[1:] { // Skip the header row
+		m := Manufacturer{Make: rec[1], Country: rec[2]}
+		if rec[3] != "" {
+			m.aliases = strings.Split(rec[3], ";")
+		}
+		table[rec[0]] = m
+	}
+	return table
+}
@@ -0,0 +1,160 @@
+package vin
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		vin  string
+		want error
+	}{
+		{"north american, check digit X", "1M8GDM9AXKP042788", nil},
+		{"north american, check digit 3", "1HGCM82633A004352", nil},
+		{"north american, wrong check digit", "1HGCM82643A004352", ErrCheckDigit},
+		{"chinese, wrong check digit", "LHGCM82643A004352", ErrCheckDigit},
+		{"european, check digit not enforced", "WVWZZZ1JZXW000001", nil},
+		{"too short", "1HGCM82633A00435", ErrInvalidFormat},
+		{"too long", "1HGCM82633A0043521", ErrInvalidFormat},
+		{"letter O", "1HGCM8263OA004352", ErrInvalidFormat},
+		{"lower case", "1hgcm82633a004352", ErrInvalidFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.vin); !errors.Is(err, tt.want) {
+				t.Errorf("Validate(%q) = %v, want %v", tt.vin, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestModelYears(t *testing.T) {
+	tests := []struct {
+		code    byte
+		maxYear int
+		want    []int
+	}{
+		{'A', 2026, []int{1980, 2010}},
+		{'Y', 2026, []int{2000}},
+		{'1', 2026, []int{2001}},
+		{'9', 2026, []int{2009}},
+		{'T', 2026, []int{1996, 2026}},
+		{'T', 2025, []int{1996}},
+		{'K', 2026, []int{1989, 2019}},
+		{'U', 2026, nil},
+		{'Z', 2026, nil},
+		{'0', 2026, nil},
+	}
+	for _, tt := range tests {
+		if got := modelYears(tt.code, tt.maxYear); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("modelYears(%q, %d) = %v, want %v", tt.code, tt.maxYear, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeManufacturer(t *testing.T) {
+	tests := []struct {
+		vin     string
+		wantWMI string
+		make    string // empty if the WMI is not in the table
+		country string
+	}{
+		{"1HGCM82633A004352", "1HG", "Honda", "United States"},
+		{" wvwzzz1jzxw000001 ", "WVW", "Volkswagen", "Germany"},
+		{"1M8GDM9AXKP042788", "1M8", "", ""},
+	}
+	for _, tt := range tests {
+		info, err := Decode(tt.vin)
+		if err != nil {
+			t.Fatalf("Decode(%q): %v", tt.vin, err)
+		}
+		if info.WMI != tt.wantWMI {
+			t.Errorf("Decode(%q).WMI = %s, want %s", tt.vin, info.WMI, tt.wantWMI)
+		}
+		if tt.make == "" {
+			if info.Manufacturer != nil {
+				t.Errorf("Decode(%q).Manufacturer = %+v, want nil", tt.vin, info.Manufacturer)
+			}
+			continue
+		}
+		if info.Manufacturer == nil || info.Manufacturer.Make != tt.make || info.Manufacturer.Country != tt.country {
+			t.Errorf("Decode(%q).Manufacturer = %+v, want %s, %s", tt.vin, info.Manufacturer, tt.make, tt.country)
+		}
+	}
+}
+
+func TestCheckMake(t *testing.T) {
+	info, err := Decode("1HGCM82633A004352")
+	if err != nil {
+		t.Fatal(err)
+	}
+	toyota, err := Decode("JTDKB20U093000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mercedes, err := Decode("WDD2050001F000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown, err := Decode("1M8GDM9AXKP042788")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		info *Info
+		make string
+		want bool
+	}{
+		{info, "Honda", true},
+		{info, " HONDA ", true},
+		{info, "Hon", false},
+		{info, "H", false},
+		{info, "Ho", false},
+		{toyota, "Toyota", true},
+		{toyota, "T", false},
+		{toyota, "To", false},
+		{mercedes, "Mercedes-Benz", true},
+		{mercedes, "mercedes benz", true},
+		{mercedes, "Mercedes", true},
+		{mercedes, "M", false},
+		{mercedes, "Me", false},
+		{info, "Toyota", false},
+		{info, "", false},
+		{unknown, "Anything", true},
+	}
+	for _, tt := range tests {
+		if got := tt.info.CheckMake(tt.make); got != tt.want {
+			t.Errorf("CheckMake(%q) for %s = %v, want %v", tt.make, tt.info.VIN, got, tt.want)
+		}
+	}
+}
+
+func TestCheckYear(t *testing.T) {
+	tests := []struct {
+		name string
+		vin  string
+		year int
+		want bool
+	}{
+		{"north american, matching year", "1HGCM82633A004352", 2003, true},
+		{"north american, other year", "1HGCM82633A004352", 2004, false},
+		{"north american, earlier cycle", "1M8GDM9AXKP042788", 1989, true},
+		{"north american, later cycle", "1M8GDM9AXKP042788", 2019, true},
+		{"european, year code not mandatory", "WVWZZZ1JZ3W000001", 2010, true},
+		{"european, no year code", "WVWZZZ1JZZW000001", 2010, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := Decode(tt.vin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := info.CheckYear(tt.year); got != tt.want {
+				t.Errorf("CheckYear(%d) = %v, want %v (model years %v)", tt.year, got, tt.want, info.ModelYears)
+			}
+		})
+	}
+}